@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lytics/gentleman"
+)
+
+func main() {
+	reqs := []string{
+		"/headers",
+		"/delay/1",
+		"/get",
+		"/ip",
+	}
+
+	// Create a new client
+	cli := gentleman.New()
+
+	// Define the base URL
+	cli.BaseURL("http://httpbin.org")
+
+	// Dispatch requests asynchronously
+	futures := make([]*gentleman.Future, len(reqs))
+	for i, path := range reqs {
+		futures[i] = cli.Request().Path(path).DoAsync()
+	}
+
+	// Wait for the responses
+	for i, future := range futures {
+		res, err := future.Wait()
+		if err != nil {
+			fmt.Printf("Request error: %s\n", err)
+			continue
+		}
+		fmt.Printf("Path: %s => Response: %d\n", reqs[i], res.StatusCode)
+		res.Close()
+	}
+
+	fmt.Printf("Done!\n")
+}
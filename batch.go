@@ -0,0 +1,164 @@
+package gentleman
+
+import (
+	gocontext "context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Batch dispatches multiple requests through the same Client
+// with bounded concurrency, returning the responses in order.
+// Response bodies are buffered before the batch completes.
+type Batch struct {
+	// client stores the Client used to dispatch the requests
+	client *Client
+
+	// requests stores the requests to dispatch
+	requests []*Request
+
+	// concurrency stores the maximum number of requests dispatched in parallel
+	concurrency int
+
+	// timeout stores the maximum amount of time the whole batch can take
+	timeout time.Duration
+
+	// failFast stores if the batch should be canceled on the first failure
+	failFast bool
+}
+
+// BatchError aggregates the errors of the failed requests in a Batch.
+type BatchError struct {
+	// Errors stores the request errors by batch position.
+	// Successful requests have a nil error.
+	Errors []error
+}
+
+// Error implements the error interface.
+func (e *BatchError) Error() string {
+	var first error
+	failed := 0
+	for _, err := range e.Errors {
+		if err == nil {
+			continue
+		}
+		if first == nil {
+			first = err
+		}
+		failed++
+	}
+	return fmt.Sprintf("gentleman: %d of %d batch requests failed: %s", failed, len(e.Errors), first)
+}
+
+// Unwrap returns the non-nil request errors.
+func (e *BatchError) Unwrap() []error {
+	errs := []error{}
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Batch creates a new Batch to dispatch the given requests.
+// Requests with no Client will inherit the current Client.
+func (c *Client) Batch(reqs ...*Request) *Batch {
+	for _, req := range reqs {
+		if req.Client == nil {
+			req.SetClient(c)
+		}
+	}
+	return &Batch{client: c, requests: reqs}
+}
+
+// Concurrency defines the maximum number of requests dispatched in parallel.
+// By default all the requests are dispatched in parallel.
+func (b *Batch) Concurrency(limit int) *Batch {
+	b.concurrency = limit
+	return b
+}
+
+// Timeout defines the maximum amount of time the whole batch can take.
+// Requests still running when the timeout is reached will be canceled.
+func (b *Batch) Timeout(timeout time.Duration) *Batch {
+	b.timeout = timeout
+	return b
+}
+
+// FailFast cancels the pending and in-flight requests
+// as soon as one request fails.
+func (b *Batch) FailFast() *Batch {
+	b.failFast = true
+	return b
+}
+
+// Do dispatches the batch requests and returns the responses in the same order.
+// If the Client defines a default context.Context, it will be used.
+// If any request fails, a *BatchError is returned.
+func (b *Batch) Do() ([]*Response, error) {
+	ctx := b.client.getContext()
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+	return b.DoContext(ctx)
+}
+
+// DoContext dispatches the batch requests bound to the given context.Context
+// and returns the responses in the same order.
+// If any request fails, a *BatchError is returned.
+func (b *Batch) DoContext(ctx gocontext.Context) ([]*Response, error) {
+	if b.timeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = gocontext.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	ctx, cancel := gocontext.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := b.concurrency
+	if concurrency <= 0 || concurrency > len(b.requests) {
+		concurrency = len(b.requests)
+	}
+
+	responses := make([]*Response, len(b.requests))
+	errs := make([]error, len(b.requests))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for i, req := range b.requests {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(i int, req *Request) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			res, err := req.DoContext(ctx)
+			if err == nil {
+				// Buffer the body before the batch context is released
+				res.populateResponseByteBuffer()
+				err = res.Error
+			}
+
+			responses[i], errs[i] = res, err
+			if err != nil && b.failFast {
+				cancel()
+			}
+		}(i, req)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return responses, &BatchError{Errors: errs}
+		}
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,113 @@
+package gentleman
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestBatch(t *testing.T) {
+	var mtx sync.Mutex
+	inflight, peak := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		inflight++
+		if inflight > peak {
+			peak = inflight
+		}
+		mtx.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, r.URL.Path+" "+r.Header.Get("Client"))
+
+		mtx.Lock()
+		inflight--
+		mtx.Unlock()
+	}))
+	defer ts.Close()
+
+	cli := New().URL(ts.URL).SetHeader("Client", "gentleman")
+	reqs := []*Request{}
+	for i := 0; i < 6; i++ {
+		reqs = append(reqs, cli.Request().Path(fmt.Sprintf("/%d", i)))
+	}
+	reqs = append(reqs, NewRequest().Path("/6"))
+
+	res, err := cli.Batch(reqs...).Concurrency(2).Do()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, len(res), 7)
+	for i, r := range res {
+		utils.Equal(t, r.StatusCode, 200)
+		utils.Equal(t, r.String(), fmt.Sprintf("/%d gentleman", i))
+	}
+	utils.Equal(t, peak <= 2, true)
+}
+
+func TestBatchCollectErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	cli := New()
+	res, err := cli.Batch(
+		cli.Request().URL(ts.URL),
+		cli.Request().URL("http://127.0.0.1:9123"),
+		cli.Request().URL(ts.URL),
+	).Do()
+
+	utils.NotEqual(t, err, nil)
+	batchErr, ok := err.(*BatchError)
+	utils.Equal(t, ok, true)
+	utils.Equal(t, batchErr.Errors[0], nil)
+	utils.NotEqual(t, batchErr.Errors[1], nil)
+	utils.Equal(t, batchErr.Errors[2], nil)
+	utils.Equal(t, res[0].String(), "Hello, world")
+	utils.Equal(t, res[1].StatusCode, 0)
+	utils.Equal(t, res[2].String(), "Hello, world")
+}
+
+func TestBatchFailFast(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		fmt.Fprint(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	cli := New()
+	failed := cli.Request().URL(ts.URL)
+	failed.UseRequest(func(ctx *context.Context, h context.Handler) {
+		h.Error(ctx, errors.New("foo error"))
+	})
+
+	start := time.Now()
+	_, err := cli.Batch(cli.Request().URL(ts.URL), failed, cli.Request().URL(ts.URL)).FailFast().Do()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, time.Since(start) < 500*time.Millisecond, true)
+
+	batchErr := err.(*BatchError)
+	utils.Equal(t, batchErr.Errors[1].Error(), "foo error")
+	utils.Equal(t, errors.Is(batchErr.Errors[0], gocontext.Canceled), true)
+	utils.Equal(t, errors.Is(batchErr.Errors[2], gocontext.Canceled), true)
+}
+
+func TestBatchTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		fmt.Fprint(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	cli := New().URL(ts.URL)
+	_, err := cli.Batch(cli.Request(), cli.Request()).Timeout(50 * time.Millisecond).Do()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, errors.Is(err, gocontext.DeadlineExceeded), true)
+}
@@ -1,6 +1,7 @@
 package gentleman
 
 import (
+	gocontext "context"
 	"net/http"
 
 	"github.com/lytics/gentleman/context"
@@ -8,6 +9,8 @@ import (
 	"github.com/lytics/gentleman/plugin"
 	"github.com/lytics/gentleman/plugins/cookies"
 	"github.com/lytics/gentleman/plugins/headers"
+	"github.com/lytics/gentleman/plugins/limit"
+	"github.com/lytics/gentleman/plugins/status"
 	"github.com/lytics/gentleman/plugins/url"
 )
 
@@ -31,6 +34,9 @@ type Client struct {
 
 	// Client entity has its own Middleware layer to compose and inherit behavior.
 	Middleware middleware.Middleware
+
+	// Optional default context.Context used by requests dispatched via Do().
+	ctx gocontext.Context
 }
 
 // New creates a new high level client entity
@@ -172,6 +178,29 @@ func (c *Client) AddCookies(data []*http.Cookie) *Client {
 // 	return c
 // }
 
+// MaxResponseSize defines the maximum size in bytes of the response body.
+// Bigger responses fail with *limit.BodyTooLargeError.
+func (c *Client) MaxResponseSize(max int64) *Client {
+	c.Use(limit.Body(max))
+	return c
+}
+
+// SpillResponse reads the whole response body before the response phase,
+// keeping in memory up to the given amount of bytes and spilling bigger bodies
+// into a temporary file. Bodies bigger than max bytes fail with *limit.BodyTooLargeError.
+func (c *Client) SpillResponse(memory, max int64) *Client {
+	c.Use(limit.Spill(memory, max))
+	return c
+}
+
+// FailOnStatus reports responses with a status code within the given ranges
+// as *status.HTTPError via the error middleware phase.
+// By default, 4xx and 5xx status codes are considered failures.
+func (c *Client) FailOnStatus(ranges ...status.Range) *Client {
+	c.Use(status.Fail(ranges...))
+	return c
+}
+
 // Use uses a new plugin to the middleware stack.
 func (c *Client) Use(p plugin.Plugin) *Client {
 	c.Middleware.Use(p)
@@ -202,6 +231,25 @@ func (c *Client) UseHandler(phase string, fn context.HandlerFunc) *Client {
 	return c
 }
 
+// UseContext defines the default context.Context used by the outgoing
+// requests created by the current Client or its child clients.
+// Requests dispatched via Request.DoContext() will use the given context instead.
+func (c *Client) UseContext(ctx gocontext.Context) *Client {
+	c.ctx = ctx
+	return c
+}
+
+// getContext returns the default context.Context looking in the parent clients recursively.
+func (c *Client) getContext() gocontext.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	if c.Parent != nil {
+		return c.Parent.getContext()
+	}
+	return nil
+}
+
 // UseParent uses another Client as parent
 // inheriting its middleware stack and configuration.
 func (c *Client) UseParent(parent *Client) *Client {
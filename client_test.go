@@ -1,6 +1,7 @@
 package gentleman
 
 import (
+	gocontext "context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/plugins/status"
 	"github.com/lytics/gentleman/utils"
 )
 
@@ -49,6 +51,53 @@ func TestClientInheritance(t *testing.T) {
 	utils.Equal(t, ctx.Request.Header.Get("Client"), "gogo")
 }
 
+func TestClientUseContext(t *testing.T) {
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+
+	parent := New().UseContext(ctx)
+	cli := New()
+	cli.UseParent(parent)
+
+	res, err := cli.Request().URL("http://127.0.0.1:9123").Send()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, errors.Is(err, gocontext.Canceled), true)
+	utils.Equal(t, res.StatusCode, 0)
+
+	req := cli.Request().URL("http://127.0.0.1:9123")
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		ctx.Response.StatusCode = 200
+		h.Next(ctx)
+	})
+	res, err = req.DoContext(gocontext.Background())
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+}
+
+func TestClientFailOnStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+		fmt.Fprint(w, "unavailable")
+	}))
+	defer ts.Close()
+
+	var phaseErr error
+	cli := New().URL(ts.URL).FailOnStatus()
+	cli.UseError(func(ctx *context.Context, h context.Handler) {
+		phaseErr = ctx.Error
+		h.Next(ctx)
+	})
+
+	res, err := cli.Request().Send()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, phaseErr, err)
+	httpErr, ok := err.(*status.HTTPError)
+	utils.Equal(t, ok, true)
+	utils.Equal(t, httpErr.StatusCode, 503)
+	utils.Equal(t, string(httpErr.Body), "unavailable")
+	utils.Equal(t, res.StatusCode, 503)
+}
+
 func TestClientRequestMiddleware(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Server", r.Header.Get("Client"))
@@ -0,0 +1,265 @@
+// Package codec implements a registry of encoders and decoders keyed by
+// media type, used to serialize request bodies and deserialize response bodies.
+//
+// Built-in codecs are provided for JSON, XML, form-urlencoded and plain text.
+// Additional wire formats, such as msgpack, CBOR, protobuf or YAML,
+// can be supported by registering a new Codec:
+//
+//	codec.Register("application/x-yaml", yamlCodec{})
+package codec
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupported is returned when there is no codec registered for a media type.
+var ErrUnsupported = errors.New("codec: unsupported media type")
+
+// Codec represents the interface implemented by media type encoders and decoders.
+type Codec interface {
+	// Encode writes the encoding of v to the given writer.
+	Encode(w io.Writer, v interface{}) error
+
+	// Decode reads the encoded value from the given reader and stores it in v.
+	Decode(r io.Reader, v interface{}) error
+}
+
+// Registry stores codecs by media type.
+type Registry struct {
+	// mtx protects codecs
+	mtx sync.RWMutex
+
+	// codecs stores the registered codecs by media type
+	codecs map[string]Codec
+}
+
+// Default stores the default codec registry, including the built-in codecs.
+var Default = NewRegistry()
+
+// NewRegistry creates a new codec registry with the built-in codecs.
+func NewRegistry() *Registry {
+	r := &Registry{codecs: map[string]Codec{}}
+	r.Register("application/json", JSON{})
+	r.Register("application/xml", XML{})
+	r.Register("text/xml", XML{})
+	r.Register("application/x-www-form-urlencoded", Form{})
+	r.Register("text/plain", Text{})
+	return r
+}
+
+// Register registers a codec for the given media type,
+// replacing any existent codec for it.
+func (r *Registry) Register(mediaType string, codec Codec) {
+	r.mtx.Lock()
+	r.codecs[normalize(mediaType)] = codec
+	r.mtx.Unlock()
+}
+
+// Unregister removes the codec registered for the given media type.
+func (r *Registry) Unregister(mediaType string) {
+	r.mtx.Lock()
+	delete(r.codecs, normalize(mediaType))
+	r.mtx.Unlock()
+}
+
+// Lookup finds the codec for the given media type or Content-Type header value.
+// Media types with a structured syntax suffix, such as application/problem+json,
+// fall back to the codec of the suffix type.
+func (r *Registry) Lookup(mediaType string) (Codec, bool) {
+	kind := normalize(mediaType)
+
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if codec, ok := r.codecs[kind]; ok {
+		return codec, true
+	}
+
+	if i := strings.LastIndex(kind, "+"); i >= 0 {
+		codec, ok := r.codecs["application/"+kind[i+1:]]
+		return codec, ok
+	}
+
+	return nil, false
+}
+
+// Encode encodes the given value based on the given media type.
+func (r *Registry) Encode(w io.Writer, v interface{}, mediaType string) error {
+	codec, ok := r.Lookup(mediaType)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupported, mediaType)
+	}
+	return codec.Encode(w, v)
+}
+
+// Decode decodes a value based on the given media type.
+func (r *Registry) Decode(rd io.Reader, v interface{}, mediaType string) error {
+	codec, ok := r.Lookup(mediaType)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupported, mediaType)
+	}
+	return codec.Decode(rd, v)
+}
+
+// Register registers a codec for the given media type in the default registry.
+func Register(mediaType string, codec Codec) {
+	Default.Register(mediaType, codec)
+}
+
+// Unregister removes the codec for the given media type in the default registry.
+func Unregister(mediaType string) {
+	Default.Unregister(mediaType)
+}
+
+// Lookup finds the codec for the given media type in the default registry.
+func Lookup(mediaType string) (Codec, bool) {
+	return Default.Lookup(mediaType)
+}
+
+// Encode encodes the given value based on the given media type
+// using the default registry.
+func Encode(w io.Writer, v interface{}, mediaType string) error {
+	return Default.Encode(w, v, mediaType)
+}
+
+// Decode decodes a value based on the given media type
+// using the default registry.
+func Decode(r io.Reader, v interface{}, mediaType string) error {
+	return Default.Decode(r, v, mediaType)
+}
+
+// normalize returns the lower case media type without parameters.
+func normalize(mediaType string) string {
+	kind, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		kind = strings.TrimSpace(strings.Split(mediaType, ";")[0])
+	}
+	return strings.ToLower(kind)
+}
+
+// JSON implements a JSON codec based on encoding/json.
+type JSON struct{}
+
+// Encode implements the Codec interface.
+func (JSON) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// Decode implements the Codec interface.
+func (JSON) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// XML implements a XML codec based on encoding/xml.
+type XML struct{}
+
+// Encode implements the Codec interface.
+func (XML) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// Decode implements the Codec interface.
+func (XML) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// Form implements a form-urlencoded codec.
+// Supports url.Values, map[string]string and map[string][]string values.
+type Form struct{}
+
+// Encode implements the Codec interface.
+func (Form) Encode(w io.Writer, v interface{}) error {
+	var values url.Values
+	switch data := v.(type) {
+	case url.Values:
+		values = data
+	case map[string][]string:
+		values = url.Values(data)
+	case map[string]string:
+		values = url.Values{}
+		for key, value := range data {
+			values.Set(key, value)
+		}
+	default:
+		return fmt.Errorf("codec: cannot encode %T as form", v)
+	}
+	_, err := io.WriteString(w, values.Encode())
+	return err
+}
+
+// Decode implements the Codec interface.
+func (Form) Decode(r io.Reader, v interface{}) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	values, err := url.ParseQuery(string(buf))
+	if err != nil {
+		return err
+	}
+
+	switch data := v.(type) {
+	case *url.Values:
+		*data = values
+	case *map[string][]string:
+		*data = values
+	case *map[string]string:
+		*data = map[string]string{}
+		for key := range values {
+			(*data)[key] = values.Get(key)
+		}
+	default:
+		return fmt.Errorf("codec: cannot decode form into %T", v)
+	}
+	return nil
+}
+
+// Text implements a plain text codec.
+// Supports strings, array of bytes and fmt.Stringer values.
+type Text struct{}
+
+// Encode implements the Codec interface.
+func (Text) Encode(w io.Writer, v interface{}) error {
+	var err error
+	switch data := v.(type) {
+	case string:
+		_, err = io.WriteString(w, data)
+	case []byte:
+		_, err = w.Write(data)
+	case fmt.Stringer:
+		_, err = io.WriteString(w, data.String())
+	default:
+		_, err = fmt.Fprint(w, v)
+	}
+	return err
+}
+
+// Decode implements the Codec interface.
+func (Text) Decode(r io.Reader, v interface{}) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	switch data := v.(type) {
+	case *string:
+		*data = string(buf)
+	case *[]byte:
+		*data = buf
+	case io.Writer:
+		_, err = data.Write(buf)
+	default:
+		return fmt.Errorf("codec: cannot decode text into %T", v)
+	}
+	return err
+}
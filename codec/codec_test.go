@@ -0,0 +1,99 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lytics/gentleman/utils"
+)
+
+type upperCodec struct{}
+
+func (upperCodec) Encode(w io.Writer, v interface{}) error {
+	_, err := io.WriteString(w, strings.ToUpper(v.(string)))
+	return err
+}
+
+func (upperCodec) Decode(r io.Reader, v interface{}) error {
+	buf, err := ioutil.ReadAll(r)
+	*(v.(*string)) = strings.ToLower(string(buf))
+	return err
+}
+
+func TestRegistryLookup(t *testing.T) {
+	cases := []struct {
+		kind  string
+		codec Codec
+	}{
+		{"application/json", JSON{}},
+		{"Application/JSON; charset=utf-8", JSON{}},
+		{"application/problem+json", JSON{}},
+		{"application/xml", XML{}},
+		{"text/xml", XML{}},
+		{"application/atom+xml", XML{}},
+		{"application/x-www-form-urlencoded", Form{}},
+		{"text/plain; charset=utf-8", Text{}},
+	}
+
+	for _, test := range cases {
+		codec, ok := Lookup(test.kind)
+		utils.Equal(t, ok, true)
+		utils.Equal(t, codec, test.codec)
+	}
+
+	_, ok := Lookup("application/x-foo")
+	utils.Equal(t, ok, false)
+	_, ok = Lookup("")
+	utils.Equal(t, ok, false)
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+	r.Register("application/x-upper", upperCodec{})
+
+	buf := &bytes.Buffer{}
+	utils.Equal(t, r.Encode(buf, "foo", "application/x-upper"), nil)
+	utils.Equal(t, buf.String(), "FOO")
+
+	var value string
+	utils.Equal(t, r.Decode(buf, &value, "application/x-upper"), nil)
+	utils.Equal(t, value, "foo")
+
+	r.Unregister("application/x-upper")
+	err := r.Encode(buf, "foo", "application/x-upper")
+	utils.Equal(t, errors.Is(err, ErrUnsupported), true)
+
+	_, ok := Lookup("application/x-upper")
+	utils.Equal(t, ok, false)
+}
+
+func TestFormCodec(t *testing.T) {
+	buf := &bytes.Buffer{}
+	utils.Equal(t, Form{}.Encode(buf, map[string]string{"foo": "bar"}), nil)
+	utils.Equal(t, buf.String(), "foo=bar")
+	utils.NotEqual(t, Form{}.Encode(buf, 123), nil)
+
+	values := url.Values{}
+	utils.Equal(t, Form{}.Decode(strings.NewReader("foo=bar&foo=baz"), &values), nil)
+	utils.Equal(t, values["foo"], []string{"bar", "baz"})
+
+	fields := map[string]string{}
+	utils.Equal(t, Form{}.Decode(strings.NewReader("foo=bar"), &fields), nil)
+	utils.Equal(t, fields, map[string]string{"foo": "bar"})
+}
+
+func TestTextCodec(t *testing.T) {
+	buf := &bytes.Buffer{}
+	utils.Equal(t, Text{}.Encode(buf, 123), nil)
+	utils.Equal(t, buf.String(), "123")
+
+	var value string
+	utils.Equal(t, Text{}.Decode(strings.NewReader("foo"), &value), nil)
+	utils.Equal(t, value, "foo")
+	utils.NotEqual(t, Text{}.Decode(strings.NewReader("foo"), value), nil)
+}
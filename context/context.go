@@ -178,6 +178,14 @@ func (c *Context) SetRequest(req *http.Request) {
 	c.Request = req.WithContext(c.Request.Context())
 }
 
+// SetContext binds the given stdlib context.Context to the current http.Request,
+// preserving the context store. Cancellation and deadlines of the given context
+// will be propagated to the HTTP transaction.
+func (c *Context) SetContext(ctx context.Context) {
+	store := c.getStore()
+	c.Request = c.Request.WithContext(context.WithValue(ctx, Key, store))
+}
+
 // Clone returns a clone of the current context.
 func (c *Context) Clone() *Context {
 	ctx := new(Context)
@@ -196,6 +204,8 @@ func (c *Context) Clone() *Context {
 }
 
 // CopyTo copies the current context store into a new Context.
+// The new Context inherits the cancellation, deadline and values
+// of the current context.Context.
 func (c *Context) CopyTo(newCtx *Context) {
 	store := Store{}
 
@@ -203,7 +213,7 @@ func (c *Context) CopyTo(newCtx *Context) {
 		store[key] = value
 	}
 
-	ctx := context.WithValue(context.Background(), Key, store)
+	ctx := context.WithValue(c.Request.Context(), Key, store)
 	newCtx.Request = newCtx.Request.WithContext(ctx)
 }
 
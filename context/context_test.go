@@ -4,6 +4,7 @@
 package context
 
 import (
+	"context"
 	"testing"
 
 	"github.com/lytics/gentleman/utils"
@@ -154,6 +155,19 @@ func TestContextClone(t *testing.T) {
 	utils.Equal(t, newCtx.Get("bar"), "bar")
 }
 
+func TestContextCloneCancel(t *testing.T) {
+	ctx := New()
+	parent, cancel := context.WithCancel(context.Background())
+	ctx.SetContext(parent)
+
+	newCtx := ctx.Clone()
+	utils.Equal(t, newCtx.Err(), nil)
+
+	cancel()
+	<-newCtx.Done()
+	utils.Equal(t, newCtx.Err(), context.Canceled)
+}
+
 func TestContextCopy(t *testing.T) {
 	ctx := New()
 	ctx.Set("bar", "foo")
@@ -183,3 +197,19 @@ func TestContextSetRequest(t *testing.T) {
 	utils.Equal(t, ctx.Get("bar"), "foo")
 	utils.Equal(t, newCtx.Get("bar"), "bar")
 }
+
+func TestContextSetContext(t *testing.T) {
+	ctx := New()
+	ctx.Set("foo", "bar")
+	utils.Equal(t, ctx.Err(), nil)
+
+	parent, cancel := context.WithCancel(context.Background())
+	ctx.SetContext(parent)
+	utils.Equal(t, ctx.Get("foo"), "bar")
+	utils.Equal(t, ctx.Err(), nil)
+
+	cancel()
+	<-ctx.Done()
+	utils.Equal(t, ctx.Err(), context.Canceled)
+	utils.Equal(t, ctx.Get("foo"), "bar")
+}
@@ -0,0 +1,212 @@
+package gentleman
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lytics/gentleman/context"
+)
+
+// CurlMaxInlineBody defines the maximum size of the request bodies
+// rendered inline in curl commands by CurlFile. Larger or binary bodies
+// are written into a file referenced via --data-binary.
+var CurlMaxInlineBody = 4096
+
+// safeShellArg matches the shell arguments which do not require quoting.
+var safeShellArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Curl returns a curl command equivalent to the HTTP request, including the method,
+// URL, headers, cookies, body, and the proxy, TLS verification and timeout options
+// derived from the configured http.Transport, http.Client and context.Context.
+// Like Dump, the request is built running the request and before dial phases
+// without dispatching it.
+// The body is always rendered inline, piping binary bodies via printf.
+func (r *Request) Curl() (string, error) {
+	cmd, _, err := r.curl(false, "")
+	return cmd, err
+}
+
+// CurlFile returns a curl command like Curl, but the bodies larger than
+// CurlMaxInlineBody or binary are written into a new file created in the given
+// directory, or the default temporary directory if empty, and referenced via
+// --data-binary. The file path is returned as bodyFile, if any, and removing
+// it is up to the caller.
+func (r *Request) CurlFile(dir string) (cmd, bodyFile string, err error) {
+	return r.curl(true, dir)
+}
+
+// curl renders the curl command, optionally writing the body into a file in dir.
+func (r *Request) curl(file bool, dir string) (cmd, bodyFile string, err error) {
+	tmp := r.Clone()
+	tmp.dispatched = true
+	if ctx := r.getContext(); ctx != nil {
+		tmp.Context.SetContext(ctx)
+	}
+
+	// Capture the transport before it is wrapped by the before dial plugins
+	var transport http.RoundTripper
+	tmp.UseRequest(func(ctx *context.Context, h context.Handler) {
+		transport = ctx.Client.Transport
+		h.Next(ctx)
+	})
+	ctx := NewDispatcher(tmp).BuildFinalRequest()
+	if ctx.Error != nil {
+		return "", "", ctx.Error
+	}
+	req := ctx.Request
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", "", err
+		}
+		if len(body) == 0 {
+			body = nil
+		}
+	}
+
+	args := []string{"curl"}
+	switch {
+	case req.Method == "HEAD":
+		args = append(args, "--head")
+	case !(req.Method == "GET" && body == nil) && !(req.Method == "POST" && body != nil):
+		args = append(args, "-X", req.Method)
+	}
+	args = append(args, req.URL.String())
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		args = append(args, "-H", "Host: "+req.Host)
+	}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "Cookie" {
+			continue
+		}
+		for _, value := range req.Header[name] {
+			args = append(args, "-H", name+": "+value)
+		}
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		// Prevent curl from defaulting to a form content type
+		args = append(args, "-H", "Content-Type:")
+	}
+
+	cookies := req.Header.Values("Cookie")
+	if ctx.Client.Jar != nil {
+		for _, cookie := range ctx.Client.Jar.Cookies(req.URL) {
+			cookies = append(cookies, cookie.Name+"="+cookie.Value)
+		}
+	}
+	if len(cookies) > 0 {
+		args = append(args, "-b", strings.Join(cookies, "; "))
+	}
+
+	var stdin string
+	text := utf8.Valid(body) && bytes.IndexByte(body, 0) == -1
+	switch {
+	case body == nil:
+	case text && (!file || len(body) <= CurlMaxInlineBody):
+		args = append(args, "--data-raw", string(body))
+	case file:
+		if bodyFile, err = writeCurlBody(dir, body); err != nil {
+			return "", "", err
+		}
+		args = append(args, "--data-binary", "@"+bodyFile)
+	default:
+		stdin = "printf " + printfFormat(body) + " | "
+		args = append(args, "--data-binary", "@-")
+	}
+
+	if t, ok := transport.(*http.Transport); ok {
+		if t.Proxy != nil {
+			proxy, err := t.Proxy(req)
+			if err != nil {
+				if bodyFile != "" {
+					os.Remove(bodyFile)
+				}
+				return "", "", err
+			}
+			if proxy != nil {
+				args = append(args, "-x", proxy.String())
+			}
+		}
+		if t.TLSClientConfig != nil && t.TLSClientConfig.InsecureSkipVerify {
+			args = append(args, "-k")
+		}
+	}
+
+	timeout := ctx.Client.Timeout
+	if deadline, ok := req.Context().Deadline(); ok {
+		if remaining := time.Until(deadline); timeout == 0 || remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if timeout > 0 {
+		args = append(args, "--max-time", strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64))
+	}
+
+	for i, arg := range args {
+		args[i] = shellQuote(arg)
+	}
+	return stdin + strings.Join(args, " "), bodyFile, nil
+}
+
+// writeCurlBody writes the given request body into a new file in the given directory.
+func writeCurlBody(dir string, body []byte) (string, error) {
+	file, err := ioutil.TempFile(dir, "gentleman-body-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(body); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// printfFormat returns the quoted printf format writing the given bytes,
+// escaping the non-printable ones as octal sequences.
+func printfFormat(body []byte) string {
+	var buf strings.Builder
+	for _, b := range body {
+		switch {
+		case b == '%':
+			buf.WriteString("%%")
+		case b == '\\':
+			buf.WriteString(`\\`)
+		case b >= ' ' && b <= '~':
+			buf.WriteByte(b)
+		default:
+			fmt.Fprintf(&buf, `\%03o`, b)
+		}
+	}
+	return shellQuote(buf.String())
+}
+
+// shellQuote quotes the given argument for POSIX shells, if required.
+func shellQuote(arg string) string {
+	if safeShellArg.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,140 @@
+package gentleman
+
+import (
+	gocontext "context"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestRequestCurl(t *testing.T) {
+	req := NewRequest().Method("POST").URL("http://example.com/users?id=1&name=o'neil")
+	req.SetHeader("X-Token", "a b").AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req.JSON(map[string]string{"name": "alice's"})
+
+	cmd, err := req.Curl()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, cmd, `curl 'http://example.com/users?id=1&name=o'\''neil' -H 'Content-Type: application/json' -H 'User-Agent: `+UserAgent+`' -H 'X-Token: a b' -b session=abc --data-raw '{"name":"alice'\''s"}`+"\n'")
+
+	// The original request is not dispatched
+	utils.Equal(t, req.dispatched, false)
+}
+
+func TestRequestCurlMethods(t *testing.T) {
+	cmd, err := NewRequest().URL("http://example.com").Curl()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, cmd, "curl http://example.com -H 'User-Agent: "+UserAgent+"'")
+
+	cmd, err = NewRequest().Method("HEAD").URL("http://example.com").Curl()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, strings.HasPrefix(cmd, "curl --head http://example.com "), true)
+
+	cmd, err = NewRequest().Method("DELETE").URL("http://example.com").Curl()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, strings.HasPrefix(cmd, "curl -X DELETE http://example.com "), true)
+
+	req := NewRequest().Method("PUT").URL("http://example.com").BodyString("data")
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		ctx.Request.Host = "api.example.com"
+		h.Next(ctx)
+	})
+	cmd, err = req.Curl()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, cmd, "curl -X PUT http://example.com -H 'Host: api.example.com' -H 'User-Agent: "+UserAgent+"' -H Content-Type: --data-raw data")
+}
+
+func TestRequestCurlBinaryBody(t *testing.T) {
+	req := NewRequest().Method("POST").URL("http://example.com").Body(strings.NewReader("a%\\'\x00\xff"))
+	req.SetHeader("Content-Type", "application/octet-stream")
+
+	cmd, err := req.Curl()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, cmd, `printf 'a%%\\'\''\000\377' | curl http://example.com -H 'Content-Type: application/octet-stream' -H 'User-Agent: `+UserAgent+`' --data-binary @-`)
+}
+
+func TestRequestCurlFile(t *testing.T) {
+	body := []byte{0, 1, 2, 255}
+	req := NewRequest().Method("POST").URL("http://example.com").Body(strings.NewReader(string(body)))
+	req.SetHeader("Content-Type", "application/octet-stream")
+
+	dir, err := ioutil.TempDir("", "curl")
+	utils.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	cmd, bodyFile, err := req.CurlFile(dir)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, filepath.Dir(bodyFile), dir)
+	utils.Equal(t, strings.HasSuffix(cmd, " --data-binary @"+bodyFile), true)
+	data, err := ioutil.ReadFile(bodyFile)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, data, body)
+
+	// Small text bodies are rendered inline
+	cmd, bodyFile, err = NewRequest().Method("POST").URL("http://example.com").BodyString("data").CurlFile(dir)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, bodyFile, "")
+	utils.Equal(t, strings.HasSuffix(cmd, " --data-raw data"), true)
+}
+
+func TestRequestCurlTransport(t *testing.T) {
+	jar, _ := cookiejar.New(nil)
+	u, _ := url.Parse("http://example.com")
+	jar.SetCookies(u, []*http.Cookie{{Name: "jar", Value: "1"}})
+
+	req := NewRequest().URL("http://example.com").AddCookie(&http.Cookie{Name: "foo", Value: "bar"})
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		proxy, _ := url.Parse("http://proxy.local:3128")
+		ctx.Client.Transport = &http.Transport{Proxy: http.ProxyURL(proxy), TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+		ctx.Client.Timeout = 1500 * time.Millisecond
+		ctx.Client.Jar = jar
+		h.Next(ctx)
+	})
+	req.UseHandler("before dial", func(ctx *context.Context, h context.Handler) {
+		ctx.Client.Transport = roundTripFunc(ctx.Client.Transport.RoundTrip)
+		h.Next(ctx)
+	})
+
+	cmd, err := req.Curl()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, cmd, "curl http://example.com -H 'User-Agent: "+UserAgent+"' -b 'foo=bar; jar=1' -x http://proxy.local:3128 -k --max-time 1.5")
+}
+
+func TestRequestCurlClientContext(t *testing.T) {
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Minute)
+	defer cancel()
+
+	cli := New().URL("http://example.com").UseContext(ctx)
+	cmd, err := cli.Request().Curl()
+	utils.Equal(t, err, nil)
+
+	parts := strings.Split(cmd, " --max-time ")
+	utils.Equal(t, len(parts), 2)
+	timeout, err := strconv.ParseFloat(parts[1], 64)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, timeout > 50 && timeout <= 60, true)
+}
+
+func TestShellQuote(t *testing.T) {
+	utils.Equal(t, shellQuote("http://example.com/a-b_c"), "http://example.com/a-b_c")
+	utils.Equal(t, shellQuote(""), "''")
+	utils.Equal(t, shellQuote("a b"), "'a b'")
+	utils.Equal(t, shellQuote("$HOME"), "'$HOME'")
+	utils.Equal(t, shellQuote("it's"), `'it'\''s'`)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -1,9 +1,29 @@
 package gentleman
 
 import (
+	"net/http/httptrace"
+
 	c "github.com/lytics/gentleman/context"
 )
 
+// CanceledError is reported when the request context.Context is canceled
+// or its deadline is exceeded before the HTTP transaction completes.
+type CanceledError struct {
+	// Err stores the original context error,
+	// either context.Canceled or context.DeadlineExceeded.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *CanceledError) Error() string {
+	return "gentleman: request canceled: " + e.Err.Error()
+}
+
+// Unwrap returns the original context error.
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
 // Dispatcher dispatches a given request triggering the middleware
 // layer per specific phase and handling the request/response/error
 // states accondingly.
@@ -62,9 +82,12 @@ func (d *Dispatcher) runDispatchTasks(tasks []task) *c.Context {
 	// Reference to initial context
 	ctx := d.req.Context
 
-	// Execute tasks in order, stopping in case of error or explicit stop.
+	// Execute tasks in order, stopping in case of error, explicit stop or cancellation.
 	for _, task := range tasks {
 		var stop bool
+		if ctx, stop = d.canceled(ctx); stop {
+			break
+		}
 		if ctx, stop = task(ctx); stop {
 			break
 		}
@@ -74,8 +97,14 @@ func (d *Dispatcher) runDispatchTasks(tasks []task) *c.Context {
 }
 
 func (d *Dispatcher) doDial(ctx *c.Context) (*c.Context, bool) {
-	// Perform the request via ctx.Client
-	res, err := ctx.Client.Do(ctx.Request)
+	// Perform the request via ctx.Client, tracing the connection timings
+	tracer := newTracer()
+	req := ctx.Request.WithContext(httptrace.WithClientTrace(ctx.Request.Context(), tracer.clientTrace()))
+	res, err := ctx.Client.Do(req)
+	ctx.Set(TimingsKey, tracer.done(res))
+	if err != nil && ctx.Err() != nil {
+		err = &CanceledError{ctx.Err()}
+	}
 	ctx.Error = err
 	if err != nil {
 		ctx = d.req.Middleware.Run("error", ctx)
@@ -137,6 +166,18 @@ func (d *Dispatcher) intercepted(ctx *c.Context) (*c.Context, bool) {
 	return ctx, true
 }
 
+func (d *Dispatcher) canceled(ctx *c.Context) (*c.Context, bool) {
+	err := ctx.Err()
+	if err == nil {
+		return ctx, false
+	}
+
+	// Report the cancellation to the error middleware and stop the pipeline
+	ctx.Error = &CanceledError{err}
+	ctx = d.req.Middleware.Run("error", ctx)
+	return ctx, true
+}
+
 func (d *Dispatcher) stop(ctx *c.Context) (*c.Context, bool) {
 	if !ctx.Stopped {
 		return ctx, false
@@ -0,0 +1,156 @@
+package gentleman
+
+import (
+	gocontext "context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Future represents the eventual result of an asynchronously dispatched Request.
+// A Future is resolved once the middleware call chain completes,
+// providing the same *Response and error returned by Request.Do().
+type Future struct {
+	// mtx protects the result and callbacks
+	mtx sync.Mutex
+
+	// done is closed once the future is resolved
+	done chan struct{}
+
+	// resolved stores if the request result is available
+	resolved bool
+
+	// cancel cancels the underlying request context
+	cancel gocontext.CancelFunc
+
+	// res and err store the request result
+	res *Response
+	err error
+
+	// then and fail store the registered callbacks
+	then []func(*Response)
+	fail []func(error)
+}
+
+// newFuture creates a new pending Future.
+func newFuture(cancel gocontext.CancelFunc) *Future {
+	return &Future{done: make(chan struct{}), cancel: cancel}
+}
+
+// Wait blocks until the future is resolved and returns the HTTP response.
+// Callbacks registered before the resolution are called before Wait returns.
+func (f *Future) Wait() (*Response, error) {
+	<-f.done
+	return f.res, f.err
+}
+
+// Done returns a channel that is closed once the future is resolved.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel cancels the in-flight request.
+// The future will be resolved with a *CanceledError.
+// Once resolved, Cancel releases the request context, aborting the
+// response body consumption, if any.
+func (f *Future) Cancel() {
+	f.cancel()
+}
+
+// Then registers a callback function to be called with the response
+// if the request succeeds. If the future is already resolved,
+// the callback is called immediately.
+func (f *Future) Then(fn func(*Response)) *Future {
+	f.mtx.Lock()
+	if !f.resolved {
+		f.then = append(f.then, fn)
+		f.mtx.Unlock()
+		return f
+	}
+	f.mtx.Unlock()
+
+	if f.err == nil {
+		fn(f.res)
+	}
+	return f
+}
+
+// OnError registers a callback function to be called with the error
+// if the request fails. If the future is already resolved,
+// the callback is called immediately.
+func (f *Future) OnError(fn func(error)) *Future {
+	f.mtx.Lock()
+	if !f.resolved {
+		f.fail = append(f.fail, fn)
+		f.mtx.Unlock()
+		return f
+	}
+	f.mtx.Unlock()
+
+	if f.err != nil {
+		fn(f.err)
+	}
+	return f
+}
+
+// resolve stores the request result, triggers the registered callbacks
+// and finally releases the waiters.
+func (f *Future) resolve(res *Response, err error) {
+	defer close(f.done)
+
+	f.mtx.Lock()
+	f.res, f.err = res, err
+	f.resolved = true
+	then, fail := f.then, f.fail
+	f.then, f.fail = nil, nil
+	f.mtx.Unlock()
+
+	if err != nil {
+		for _, fn := range fail {
+			fn(err)
+		}
+		return
+	}
+	for _, fn := range then {
+		fn(res)
+	}
+}
+
+// releaseOnClose releases the request context once the response body is closed,
+// or immediately if the request failed or there is no body to consume.
+func releaseOnClose(res *Response, err error, cancel gocontext.CancelFunc) {
+	if err != nil || res == nil || res.RawResponse == nil {
+		cancel()
+		return
+	}
+
+	body := res.RawResponse.Body
+	if body == nil || body == http.NoBody {
+		cancel()
+		return
+	}
+
+	cb := &cancelBody{ReadCloser: body, cancel: cancel}
+	if w, ok := body.(io.Writer); ok {
+		// Upgraded connections bodies must remain writable
+		res.RawResponse.Body = struct {
+			*cancelBody
+			io.Writer
+		}{cb, w}
+		return
+	}
+	res.RawResponse.Body = cb
+}
+
+// cancelBody releases the request context once closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel gocontext.CancelFunc
+}
+
+// Close implements the io.Closer interface.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
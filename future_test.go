@@ -0,0 +1,98 @@
+package gentleman
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestFutureWait(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	var phases []string
+	req := NewRequest().URL(ts.URL)
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		phases = append(phases, "request")
+		h.Next(ctx)
+	})
+	req.UseResponse(func(ctx *context.Context, h context.Handler) {
+		phases = append(phases, "response")
+		h.Next(ctx)
+	})
+
+	f := req.DoAsync()
+	<-f.Done()
+	res, err := f.Wait()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "Hello, world\n")
+	utils.Equal(t, phases, []string{"request", "response"})
+}
+
+func TestFutureCallbacks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintln(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	done := make(chan int, 1)
+	f := NewRequest().URL(ts.URL).DoAsync()
+	f.Then(func(res *Response) {
+		done <- res.StatusCode
+	}).OnError(func(err error) {
+		t.Errorf("Unexpected error: %s", err)
+	})
+	utils.Equal(t, <-done, 200)
+
+	// Callbacks registered after resolution are called immediately
+	called := false
+	f.Then(func(res *Response) { called = true })
+	utils.Equal(t, called, true)
+}
+
+func TestFutureCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		fmt.Fprintln(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	var failure error
+	f := NewRequest().URL(ts.URL).DoAsyncContext(gocontext.Background())
+	f.OnError(func(err error) { failure = err })
+	f.Cancel()
+
+	res, err := f.Wait()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, errors.Is(err, gocontext.Canceled), true)
+	utils.Equal(t, res.StatusCode, 0)
+	utils.Equal(t, failure, err)
+}
+
+func TestFutureReleaseContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	res, err := NewRequest().URL(ts.URL).DoAsyncContext(gocontext.Background()).Wait()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.RawRequest.Context().Err(), nil)
+	utils.Equal(t, res.String(), "Hello, world\n")
+	utils.Equal(t, res.RawRequest.Context().Err(), gocontext.Canceled)
+
+	res, err = NewRequest().URL("http://127.0.0.1:0").DoAsyncContext(gocontext.Background()).Wait()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, res.RawRequest.Context().Err(), gocontext.Canceled)
+}
@@ -42,6 +42,17 @@ func (m *Mux) Match(ctx *c.Context) bool {
 	return true
 }
 
+// MatchRequest matches the given Context against the given matcher as in the request
+// phase, regardless of the current middleware phase.
+// This allows plugins running in later phases, such as "before dial",
+// to match the outgoing request with the request matchers.
+func MatchRequest(ctx *c.Context, matcher Matcher) bool {
+	phase := ctx.GetString("$phase")
+	ctx.Set("$phase", "request")
+	defer ctx.Set("$phase", phase)
+	return matcher(ctx)
+}
+
 // AddMatcher adds a new matcher function in the current mumultiplexer matchers stack.
 func (m *Mux) AddMatcher(matchers ...Matcher) *Mux {
 	m.Matchers = append(m.Matchers, matchers...)
@@ -18,6 +18,18 @@ func TestMuxSimple(t *testing.T) {
 	utils.Equal(t, ctx.Request.Header.Get("foo"), "bar")
 }
 
+func TestMatchRequest(t *testing.T) {
+	ctx := context.New()
+	ctx.Set("$phase", "before dial")
+	ctx.Request.URL.Path = "/users"
+
+	mx := Path("/users")
+	utils.Equal(t, mx.Match(ctx), false)
+	utils.Equal(t, MatchRequest(ctx, mx.Match), true)
+	utils.Equal(t, MatchRequest(ctx, Path("/posts").Match), false)
+	utils.Equal(t, ctx.GetString("$phase"), "before dial")
+}
+
 type handler struct {
 	fn     context.Handler
 	called bool
@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// digestAlgorithms stores the supported Digest algorithms by preference.
+var digestAlgorithms = map[string]int{
+	"MD5":              1,
+	"MD5-SESS":         1,
+	"SHA-256":          2,
+	"SHA-256-SESS":     2,
+	"SHA-512-256":      3,
+	"SHA-512-256-SESS": 3,
+}
+
+// DigestAuth implements the HTTP Digest access authentication scheme
+// as defined by RFC 7616. The server challenges are cached per host,
+// so the plugin should be used at client level to authenticate
+// the later requests preemptively.
+type DigestAuth struct {
+	p.Plugin
+
+	// username and password store the user credentials
+	username, password string
+
+	// mtx protects challenges
+	mtx sync.Mutex
+
+	// challenges stores the last server challenge by host
+	challenges map[string]*challenge
+}
+
+// challenge represents a Digest authentication challenge.
+type challenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	userhash  bool
+	count     int
+}
+
+// Digest creates a new plugin authenticating the outgoing requests using
+// the Digest scheme. The initial 401 challenge is handled transparently
+// by replaying the request once with the computed credentials.
+func Digest(username, password string) *DigestAuth {
+	d := &DigestAuth{username: username, password: password, challenges: map[string]*challenge{}}
+	d.Plugin = p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		ctx.Client.Transport = &DigestTransport{d, ctx.Client.Transport, ctx}
+		h.Next(ctx)
+	})
+	return d
+}
+
+// DigestTransport provides a http.RoundTripper compatible transport who encapsulates
+// the original http.Transport and answers the Digest authentication challenges.
+type DigestTransport struct {
+	auth      *DigestAuth
+	transport http.RoundTripper
+	context   *c.Context
+}
+
+// RoundTrip implements the required method by http.RoundTripper interface.
+func (t *DigestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Restore original http.Transport
+	t.context.Client.Transport = t.transport
+
+	// Cache the body buffer to be able to replay the request
+	var buf []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if buf, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	host := req.URL.Host
+	res, err := t.send(req, buf, t.auth.authorization(host, req, buf))
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		if err == nil {
+			t.auth.update(host, res)
+		}
+		return res, err
+	}
+
+	ch := parseChallenge(res.Header.Values("WWW-Authenticate"))
+	if ch == nil {
+		return res, nil
+	}
+
+	// Replay the request once answering the new challenge
+	t.auth.mtx.Lock()
+	t.auth.challenges[host] = ch
+	t.auth.mtx.Unlock()
+
+	res.Body.Close()
+	res, err = t.send(req, buf, t.auth.authorization(host, req, buf))
+	if err == nil {
+		t.auth.update(host, res)
+	}
+	return res, err
+}
+
+// send sends a copy of the given request with the given authorization.
+func (t *DigestTransport) send(req *http.Request, buf []byte, authorization string) (*http.Response, error) {
+	reqCopy := req.Clone(req.Context())
+	if authorization != "" {
+		reqCopy.Header.Set("Authorization", authorization)
+	}
+	if buf != nil {
+		reqCopy.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	}
+	return t.transport.RoundTrip(reqCopy)
+}
+
+// authorization returns the Authorization header answering the cached
+// challenge of the given host, incrementing the nonce count.
+// It returns an empty string if there is no challenge.
+func (d *DigestAuth) authorization(host string, req *http.Request, body []byte) string {
+	d.mtx.Lock()
+	ch, ok := d.challenges[host]
+	if !ok {
+		d.mtx.Unlock()
+		return ""
+	}
+	ch.count++
+	answer := *ch
+	d.mtx.Unlock()
+
+	cnonce := make([]byte, 16)
+	rand.Read(cnonce)
+	return answer.authorization(d.username, d.password, req.Method, req.URL.RequestURI(), body, hex.EncodeToString(cnonce))
+}
+
+// update updates the cached nonce with the nextnonce parameter
+// of the Authentication-Info response header, if present.
+func (d *DigestAuth) update(host string, res *http.Response) {
+	info := parseParams(res.Header.Get("Authentication-Info"))
+	if info["nextnonce"] == "" {
+		return
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if ch, ok := d.challenges[host]; ok {
+		ch.nonce = info["nextnonce"]
+		ch.count = 0
+	}
+}
+
+// authorization computes the Authorization header answering the challenge.
+func (ch *challenge) authorization(username, password, method, uri string, body []byte, cnonce string) string {
+	h := ch.hash()
+	nc := fmt.Sprintf("%08x", ch.count)
+
+	ha1 := h(username + ":" + ch.realm + ":" + password)
+	if strings.HasSuffix(strings.ToUpper(ch.algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + ch.nonce + ":" + cnonce)
+	}
+
+	ha2 := h(method + ":" + uri)
+	if ch.qop == "auth-int" {
+		ha2 = h(method + ":" + uri + ":" + h(string(body)))
+	}
+
+	response := h(ha1 + ":" + ch.nonce + ":" + ha2)
+	if ch.qop != "" {
+		response = h(strings.Join([]string{ha1, ch.nonce, nc, cnonce, ch.qop, ha2}, ":"))
+	}
+
+	if ch.userhash {
+		username = h(username + ":" + ch.realm)
+	}
+
+	params := []string{
+		"username=" + quote(username),
+		"realm=" + quote(ch.realm),
+		"uri=" + quote(uri),
+		"algorithm=" + ch.algorithm,
+		"nonce=" + quote(ch.nonce),
+	}
+	if ch.qop != "" {
+		params = append(params, "nc="+nc, "cnonce="+quote(cnonce), "qop="+ch.qop)
+	}
+	params = append(params, "response="+quote(response))
+	if ch.opaque != "" {
+		params = append(params, "opaque="+quote(ch.opaque))
+	}
+	if ch.userhash {
+		params = append(params, "userhash=true")
+	}
+	return "Digest " + strings.Join(params, ", ")
+}
+
+// hash returns the hex-encoded hash function of the challenge algorithm.
+func (ch *challenge) hash() func(string) string {
+	var fn func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(ch.algorithm), "-SESS") {
+	case "SHA-256":
+		fn = sha256.New
+	case "SHA-512-256":
+		fn = sha512.New512_256
+	default:
+		fn = md5.New
+	}
+	return func(s string) string {
+		h := fn()
+		h.Write([]byte(s))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// parseChallenge parses the given WWW-Authenticate header values,
+// returning the Digest challenge with the strongest supported algorithm.
+func parseChallenge(values []string) *challenge {
+	var best *challenge
+	for _, value := range values {
+		if len(value) < 7 || !strings.EqualFold(value[:7], "Digest ") {
+			continue
+		}
+
+		params := parseParams(value[7:])
+		ch := &challenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+			userhash:  strings.EqualFold(params["userhash"], "true"),
+		}
+		if ch.algorithm == "" {
+			ch.algorithm = "MD5"
+		}
+		if digestAlgorithms[strings.ToUpper(ch.algorithm)] == 0 || ch.nonce == "" {
+			continue
+		}
+
+		// Prefer qop=auth, since auth-int requires the whole body
+		if qop, ok := params["qop"]; ok {
+			for _, option := range strings.Split(qop, ",") {
+				option = strings.TrimSpace(option)
+				if option == "auth" || (option == "auth-int" && ch.qop == "") {
+					ch.qop = option
+				}
+			}
+			if ch.qop == "" {
+				continue
+			}
+		}
+
+		if best == nil || digestAlgorithms[strings.ToUpper(ch.algorithm)] > digestAlgorithms[strings.ToUpper(best.algorithm)] {
+			best = ch
+		}
+	}
+	return best
+}
+
+// parseParams parses the comma-separated auth parameters, unquoting quoted values.
+func parseParams(s string) map[string]string {
+	params := map[string]string{}
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t,")
+		i := strings.Index(s, "=")
+		if i < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:i]))
+		s = strings.TrimLeft(s[i+1:], " \t")
+
+		value := &strings.Builder{}
+		if strings.HasPrefix(s, `"`) {
+			i = 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				value.WriteByte(s[i])
+			}
+			s = s[min(i+1, len(s)):]
+		} else {
+			i = strings.Index(s, ",")
+			if i < 0 {
+				i = len(s)
+			}
+			value.WriteString(strings.TrimSpace(s[:i]))
+			s = s[i:]
+		}
+		params[key] = value.String()
+	}
+	return params
+}
+
+// quote returns the given value as a quoted string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
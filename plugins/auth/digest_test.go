@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lytics/gentleman"
+	"github.com/lytics/gentleman/utils"
+)
+
+// Test vectors from RFC 7616, Section 3.9.1.
+func TestDigestAuthorization(t *testing.T) {
+	ch := &challenge{
+		realm:     "http-auth@example.org",
+		nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+		opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+		algorithm: "MD5",
+		qop:       "auth",
+		count:     1,
+	}
+	cnonce := "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+
+	auth := ch.authorization("Mufasa", "Circle of Life", "GET", "/dir/index.html", nil, cnonce)
+	utils.Equal(t, auth, `Digest username="Mufasa", realm="http-auth@example.org", uri="/dir/index.html", algorithm=MD5, `+
+		`nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", nc=00000001, cnonce="f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", `+
+		`qop=auth, response="8ca523f5e9506fed4657c9700eebdbec", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`)
+
+	ch.algorithm = "SHA-256"
+	auth = ch.authorization("Mufasa", "Circle of Life", "GET", "/dir/index.html", nil, cnonce)
+	utils.Equal(t, parseParams(auth[7:])["response"], "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1")
+}
+
+func TestDigestParseChallenge(t *testing.T) {
+	ch := parseChallenge([]string{
+		`Basic realm="foo"`,
+		`Digest realm="api", qop="auth-int, auth", algorithm=MD5, nonce="abc", opaque="xyz"`,
+		`Digest realm="api", qop="auth-int", algorithm=SHA-256, nonce="def", userhash=true`,
+		`Digest realm="api", algorithm=SHA-1, nonce="ghi"`,
+	})
+	utils.Equal(t, ch.algorithm, "SHA-256")
+	utils.Equal(t, ch.qop, "auth-int")
+	utils.Equal(t, ch.nonce, "def")
+	utils.Equal(t, ch.userhash, true)
+
+	ch = parseChallenge([]string{`Digest realm="a \"b\"", qop="auth-int,auth", nonce=abc`})
+	utils.Equal(t, ch.realm, `a "b"`)
+	utils.Equal(t, ch.qop, "auth")
+	utils.Equal(t, ch.algorithm, "MD5")
+
+	utils.Equal(t, parseChallenge([]string{`Basic realm="foo"`}), (*challenge)(nil))
+}
+
+// digestServer implements a Digest authentication server, rotating the
+// nonce after the given number of requests and verifying the nonce count.
+type digestServer struct {
+	qop    string
+	nonces []string
+	rotate int
+	hits   int32
+	authed int32
+	count  int
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	hits := atomic.AddInt32(&s.hits, 1)
+	nonce := s.nonces[0]
+	if s.rotate > 0 && int(hits) > s.rotate {
+		nonce = s.nonces[1]
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	params := parseParams(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "))
+	if params["nonce"] == nonce && params["nc"] != "" {
+		ch := &challenge{realm: "api", nonce: nonce, algorithm: params["algorithm"], qop: params["qop"], opaque: "op", userhash: params["userhash"] == "true"}
+		ch.count = s.count + 1
+		expected := parseParams(ch.authorization("user", "pass", r.Method, params["uri"], body, params["cnonce"])[7:])
+		if expected["response"] == params["response"] && expected["nc"] == params["nc"] && params["opaque"] == "op" {
+			s.count++
+			atomic.AddInt32(&s.authed, 1)
+			w.Write(body)
+			return
+		}
+	}
+
+	stale := ""
+	if params["nonce"] != "" && params["nonce"] != nonce {
+		stale = ", stale=true"
+		s.count = 0
+	}
+	w.Header().Add("WWW-Authenticate", `Basic realm="api"`)
+	w.Header().Add("WWW-Authenticate", `Digest realm="api", qop="`+s.qop+`", algorithm=SHA-256, nonce="`+nonce+`", opaque="op"`+stale)
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func TestDigestPlugin(t *testing.T) {
+	server := &digestServer{qop: "auth", nonces: []string{"n1", "n2"}, rotate: 3}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(Digest("user", "pass"))
+
+	res, err := cli.Request().Method("POST").BodyString("hello").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "hello")
+	utils.Equal(t, atomic.LoadInt32(&server.hits), int32(2))
+
+	// Later requests authenticate preemptively
+	res, err = cli.Request().Path("/foo").AddQuery("a", "b").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, atomic.LoadInt32(&server.hits), int32(3))
+
+	// Stale nonces are renewed transparently
+	res, err = cli.Request().Method("PUT").BodyString("world").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "world")
+	utils.Equal(t, atomic.LoadInt32(&server.hits), int32(5))
+	utils.Equal(t, atomic.LoadInt32(&server.authed), int32(3))
+}
+
+func TestDigestPluginAuthInt(t *testing.T) {
+	server := &digestServer{qop: "auth-int", nonces: []string{"n1"}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	res, err := gentleman.New().URL(ts.URL).Use(Digest("user", "pass")).Request().Method("POST").BodyString("hello").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "hello")
+
+	res, err = gentleman.New().URL(ts.URL).Use(Digest("user", "wrong")).Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 401)
+}
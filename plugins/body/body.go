@@ -2,14 +2,14 @@ package body
 
 import (
 	"bytes"
-	"encoding/json"
-	"encoding/xml"
 	"io"
 	"io/ioutil"
 	"strings"
 
+	"github.com/lytics/gentleman/codec"
 	c "github.com/lytics/gentleman/context"
 	p "github.com/lytics/gentleman/plugin"
+	"github.com/lytics/gentleman/plugins/bodytype"
 	"github.com/lytics/gentleman/utils"
 )
 
@@ -26,33 +26,24 @@ func String(data string) p.Plugin {
 // JSON defines a JSON body in the outgoing request.
 // Supports strings, array of bytes or buffer.
 func JSON(data interface{}) p.Plugin {
-	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
-		buf := &bytes.Buffer{}
-
-		switch data.(type) {
-		case string:
-			buf.WriteString(data.(string))
-		case []byte:
-			buf.Write(data.([]byte))
-		default:
-			if err := json.NewEncoder(buf).Encode(data); err != nil {
-				h.Error(ctx, err)
-				return
-			}
-		}
-
-		ctx.Request.Method = getMethod(ctx)
-		ctx.Request.Body = ioutil.NopCloser(buf)
-		ctx.Request.ContentLength = int64(buf.Len())
-		ctx.Request.Header.Set("Content-Type", "application/json")
-
-		h.Next(ctx)
-	})
+	return Encode(data, "application/json")
 }
 
 // XML defines a XML body in the outgoing request.
 // Supports strings, array of bytes or buffer.
 func XML(data interface{}) p.Plugin {
+	return Encode(data, "application/xml")
+}
+
+// Encode defines a body in the outgoing request serialized by the codec
+// registered for the given media type or type alias, such as json or xml.
+// Strings and array of bytes are written as is.
+// The Content-Type header will be defined with the given media type.
+func Encode(data interface{}, mediaType string) p.Plugin {
+	if alias, ok := bodytype.Types[mediaType]; ok {
+		mediaType = alias
+	}
+
 	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
 		buf := &bytes.Buffer{}
 
@@ -62,7 +53,7 @@ func XML(data interface{}) p.Plugin {
 		case []byte:
 			buf.Write(data.([]byte))
 		default:
-			if err := xml.NewEncoder(buf).Encode(data); err != nil {
+			if err := codec.Encode(buf, data, mediaType); err != nil {
 				h.Error(ctx, err)
 				return
 			}
@@ -71,7 +62,7 @@ func XML(data interface{}) p.Plugin {
 		ctx.Request.Method = getMethod(ctx)
 		ctx.Request.Body = ioutil.NopCloser(buf)
 		ctx.Request.ContentLength = int64(buf.Len())
-		ctx.Request.Header.Set("Content-Type", "application/xml")
+		ctx.Request.Header.Set("Content-Type", mediaType)
 
 		h.Next(ctx)
 	})
@@ -106,6 +106,30 @@ func TestBodyXMLEncodeBytes(t *testing.T) {
 	utils.Equal(t, string(buf), `<test>foo</test>`)
 }
 
+func TestBodyEncodeForm(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+
+	Encode(map[string]string{"foo": "bar"}, "form").Exec("request", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+
+	buf, err := ioutil.ReadAll(ctx.Request.Body)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, ctx.Request.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+	utils.Equal(t, int(ctx.Request.ContentLength), 7)
+	utils.Equal(t, string(buf), "foo=bar")
+}
+
+func TestBodyEncodeUnsupported(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+
+	Encode(map[string]string{"foo": "bar"}, "application/x-foo").Exec("request", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+	utils.NotEqual(t, ctx.Error, nil)
+	utils.Equal(t, ctx.Error.Error(), "codec: unsupported media type: application/x-foo")
+}
+
 func TestBodyReader(t *testing.T) {
 	ctx := context.New()
 	ctx.Request.Method = "POST"
@@ -0,0 +1,432 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/mux"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+const (
+	// OpenTimeout defines the default time the circuit stays open
+	// before allowing probe requests.
+	OpenTimeout = 30 * time.Second
+
+	// Window defines the default sliding window size used to calculate the failure ratio.
+	Window = time.Minute
+)
+
+// Context store keys used internally during the HTTP transaction.
+const (
+	keyKey        = "$breaker.key"
+	generationKey = "$breaker.generation"
+
+	// timingsKey is set by gentleman once the request is dialed,
+	// even if the dial fails.
+	timingsKey = "$timings"
+)
+
+// ErrOpen is wrapped by OpenError when a request is rejected by an open circuit.
+var ErrOpen = errors.New("breaker: circuit open")
+
+// State represents the circuit breaker state.
+type State int
+
+const (
+	// Closed allows all the requests, tracking their outcome.
+	Closed State = iota
+
+	// Open rejects all the requests until the open timeout expires.
+	Open
+
+	// HalfOpen allows a limited number of probe requests
+	// to determine if the circuit can be closed again.
+	HalfOpen
+)
+
+// String returns the state name.
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// OpenError is reported when a request is rejected by an open circuit.
+type OpenError struct {
+	// Key stores the circuit key.
+	Key string
+
+	// State stores the circuit state.
+	State State
+
+	// RetryAfter stores the time until the circuit allows probe requests.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s for %s (%s)", ErrOpen, e.Key, e.State)
+}
+
+// Unwrap returns ErrOpen.
+func (e *OpenError) Unwrap() error {
+	return ErrOpen
+}
+
+// KeyFunc returns the circuit key for the given HTTP transaction context.
+// An empty key means the request is not guarded by the breaker.
+type KeyFunc func(ctx *c.Context) string
+
+// Host keys circuits by the target URL host.
+func Host(ctx *c.Context) string {
+	return ctx.Request.URL.Host
+}
+
+// Route keys requests matching the given multiplexer with the given name.
+// Requests not matching the multiplexer are not guarded by the breaker.
+func Route(name string, m *mux.Mux) KeyFunc {
+	return func(ctx *c.Context) string {
+		// The key may be resolved before dialing, once the outgoing request is fully built
+		if mux.MatchRequest(ctx, m.Match) {
+			return name
+		}
+		return ""
+	}
+}
+
+// Timeout returns a new multiplexer who matches network timeout errors
+// and exceeded deadlines.
+func Timeout() *mux.Mux {
+	return mux.Match(func(ctx *c.Context) bool {
+		if ctx.GetString("$phase") != "error" || ctx.Error == nil {
+			return false
+		}
+		var netErr net.Error
+		return errors.Is(ctx.Error, context.DeadlineExceeded) ||
+			(errors.As(ctx.Error, &netErr) && netErr.Timeout())
+	})
+}
+
+// Options represents the circuit breaker options.
+type Options struct {
+	// Key defines the function used to group requests in circuits.
+	// Defaults to Host.
+	Key KeyFunc
+
+	// Failure defines the multiplexer used to classify failed requests,
+	// evaluated in the response and error phases.
+	// Errors raised before dialing and caller cancellations are never recorded.
+	// Defaults to mux.Error(), matching errors and 5xx responses.
+	Failure *mux.Mux
+
+	// ConsecutiveFailures defines the number of consecutive failures
+	// that opens the circuit. Zero disables it.
+	ConsecutiveFailures int
+
+	// FailureRatio defines the failure ratio within the sliding window,
+	// between 0 and 1, that opens the circuit. Zero disables it.
+	FailureRatio float64
+
+	// MinRequests defines the minimum number of requests within the window
+	// to evaluate the failure ratio.
+	MinRequests int
+
+	// Window defines the sliding window size. Defaults to Window.
+	Window time.Duration
+
+	// OpenTimeout defines the time the circuit stays open. Defaults to OpenTimeout.
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests defines the number of successful probe requests
+	// required to close the circuit. Defaults to 1.
+	HalfOpenRequests int
+
+	// OnStateChange is called when a circuit changes its state.
+	OnStateChange func(key string, from, to State)
+}
+
+// circuit stores the state of a single circuit.
+type circuit struct {
+	state      State
+	generation int
+	failures   int
+	probes     int
+	successes  int
+	openedAt   time.Time
+	window     window
+}
+
+// Breaker stores the circuits by key.
+// It implements the plugin interface, so the circuits state is shared across
+// all the requests dispatched by a Client and its children via UseParent.
+type Breaker struct {
+	p.Plugin
+
+	// opts stores the breaker options
+	opts Options
+
+	// mtx protects circuits
+	mtx sync.Mutex
+
+	// circuits stores the circuits by key
+	circuits map[string]*circuit
+}
+
+// New creates a new circuit breaker plugin with the given options.
+// Requests are checked in the request phase, or before dialing if
+// the circuit key cannot be resolved yet, failing with *OpenError
+// if the circuit is open.
+func New(opts Options) *Breaker {
+	if opts.Key == nil {
+		opts.Key = Host
+	}
+	if opts.Failure == nil {
+		opts.Failure = mux.Error()
+	}
+	if opts.Window <= 0 {
+		opts.Window = Window
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = OpenTimeout
+	}
+	if opts.HalfOpenRequests < 1 {
+		opts.HalfOpenRequests = 1
+	}
+
+	b := &Breaker{opts: opts, circuits: map[string]*circuit{}}
+
+	plu := p.New()
+	plu.SetHandler("request", b.check)
+	plu.SetHandler("before dial", b.check)
+	plu.SetHandler("response", b.record)
+	plu.SetHandler("error", b.record)
+	plu.SetHandler("intercept", b.release)
+	plu.SetHandler("stop", b.release)
+	b.Plugin = plu
+	return b
+}
+
+// State returns the current state of the circuit for the given key.
+func (b *Breaker) State(key string) State {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if cb, ok := b.circuits[key]; ok {
+		return cb.state
+	}
+	return Closed
+}
+
+// Reset closes the circuit for the given key, clearing its counters.
+func (b *Breaker) Reset(key string) {
+	b.mtx.Lock()
+	from := Closed
+	if cb, ok := b.circuits[key]; ok {
+		from = cb.state
+		delete(b.circuits, key)
+	}
+	b.mtx.Unlock()
+
+	b.notifyChange(key, from, Closed)
+}
+
+// check verifies if the outgoing request is allowed by its circuit.
+func (b *Breaker) check(ctx *c.Context, h c.Handler) {
+	if ctx.GetString(keyKey) != "" {
+		h.Next(ctx)
+		return
+	}
+
+	key := b.opts.Key(ctx)
+	if key == "" {
+		h.Next(ctx)
+		return
+	}
+
+	generation, err := b.allow(key, time.Now())
+	if err != nil {
+		h.Error(ctx, err)
+		return
+	}
+
+	ctx.Set(keyKey, key)
+	ctx.Set(generationKey, generation)
+	h.Next(ctx)
+}
+
+// record records the outcome of the HTTP transaction in its circuit.
+func (b *Breaker) record(ctx *c.Context, h c.Handler) {
+	key := ctx.GetString(keyKey)
+	if key == "" {
+		h.Next(ctx)
+		return
+	}
+	generation, _ := ctx.GetInt(generationKey)
+
+	// Record the outcome only once per transaction
+	ctx.Delete(keyKey)
+
+	// Errors raised before dialing, such as rate limits or body encoding errors,
+	// and caller cancellations have no outcome, since the server was not involved
+	_, dialed := ctx.GetOk(timingsKey)
+	canceled := ctx.Error != nil && (!dialed || errors.Is(ctx.Error, context.Canceled))
+	b.done(key, generation, b.opts.Failure.Match(ctx), canceled, time.Now())
+	h.Next(ctx)
+}
+
+// release releases the request allowed by its circuit without recording an outcome,
+// since stopped and intercepted requests never reach the server.
+// This frees the half-open probe slot taken by the request.
+func (b *Breaker) release(ctx *c.Context, h c.Handler) {
+	key := ctx.GetString(keyKey)
+	if key == "" {
+		h.Next(ctx)
+		return
+	}
+	generation, _ := ctx.GetInt(generationKey)
+	ctx.Delete(keyKey)
+
+	b.done(key, generation, false, true, time.Now())
+	h.Next(ctx)
+}
+
+// allow verifies if a request is allowed by the circuit of the given key,
+// returning the circuit generation.
+func (b *Breaker) allow(key string, now time.Time) (int, error) {
+	b.mtx.Lock()
+	cb, ok := b.circuits[key]
+	if !ok {
+		cb = &circuit{window: window{size: b.opts.Window}}
+		b.circuits[key] = cb
+	}
+
+	from := cb.state
+	generation, err := b.acquire(key, cb, now)
+	to := cb.state
+	b.mtx.Unlock()
+
+	b.notifyChange(key, from, to)
+	return generation, err
+}
+
+// acquire allows a request in the given circuit, if possible.
+// Open circuits become half-open once the open timeout expires.
+func (b *Breaker) acquire(key string, cb *circuit, now time.Time) (int, error) {
+	if cb.state == Open {
+		if wait := cb.openedAt.Add(b.opts.OpenTimeout).Sub(now); wait > 0 {
+			return 0, &OpenError{Key: key, State: Open, RetryAfter: wait}
+		}
+		b.transition(cb, HalfOpen, now)
+	}
+
+	if cb.state == HalfOpen {
+		if cb.probes >= b.opts.HalfOpenRequests {
+			return 0, &OpenError{Key: key, State: HalfOpen}
+		}
+		cb.probes++
+	}
+
+	return cb.generation, nil
+}
+
+// done records a request outcome in the circuit of the given key.
+func (b *Breaker) done(key string, generation int, failed, canceled bool, now time.Time) {
+	b.mtx.Lock()
+	cb, ok := b.circuits[key]
+	if !ok || cb.generation != generation {
+		// The circuit changed its state since the request was allowed
+		b.mtx.Unlock()
+		return
+	}
+
+	from := cb.state
+	switch {
+	case canceled:
+		// Canceled and released requests have no outcome
+		if cb.state == HalfOpen {
+			cb.probes--
+		}
+
+	case cb.state == HalfOpen && failed:
+		b.transition(cb, Open, now)
+
+	case cb.state == HalfOpen:
+		cb.successes++
+		if cb.successes >= b.opts.HalfOpenRequests {
+			b.transition(cb, Closed, now)
+		}
+
+	case failed:
+		cb.failures++
+		cb.window.add(now, true)
+		if b.trip(cb, now) {
+			b.transition(cb, Open, now)
+		}
+
+	default:
+		cb.failures = 0
+		cb.window.add(now, false)
+	}
+
+	to := cb.state
+	b.mtx.Unlock()
+
+	b.notifyChange(key, from, to)
+}
+
+// trip returns true if the circuit failures exceed the thresholds.
+func (b *Breaker) trip(cb *circuit, now time.Time) bool {
+	if b.opts.ConsecutiveFailures > 0 && cb.failures >= b.opts.ConsecutiveFailures {
+		return true
+	}
+	if b.opts.FailureRatio <= 0 {
+		return false
+	}
+
+	total, failures := cb.window.counts(now)
+	if total == 0 || total < b.opts.MinRequests {
+		return false
+	}
+	return float64(failures)/float64(total) >= b.opts.FailureRatio
+}
+
+// transition changes the circuit state, resetting its counters.
+func (b *Breaker) transition(cb *circuit, state State, now time.Time) {
+	cb.state = state
+	cb.generation++
+	cb.failures = 0
+	cb.probes = 0
+	cb.successes = 0
+	if state == Open {
+		cb.openedAt = now
+	}
+	if state == Closed {
+		cb.window.reset()
+	}
+}
+
+// notifyChange calls the state change callback if the state changed.
+func (b *Breaker) notifyChange(key string, from, to State) {
+	if from != to {
+		b.notify(key, from, to)
+	}
+}
+
+// notify calls the state change callback, if present.
+func (b *Breaker) notify(key string, from, to State) {
+	if b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(key, from, to)
+	}
+}
@@ -0,0 +1,267 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman"
+	c "github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/mux"
+	"github.com/lytics/gentleman/plugins/timeout"
+	"github.com/lytics/gentleman/utils"
+)
+
+type transition struct {
+	from, to State
+}
+
+func newServer(fail *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(fail) == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+}
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	fail := int32(1)
+	ts := newServer(&fail)
+	defer ts.Close()
+
+	var mtx sync.Mutex
+	transitions := []transition{}
+
+	b := New(Options{
+		ConsecutiveFailures: 2,
+		OpenTimeout:         50 * time.Millisecond,
+		OnStateChange: func(key string, from, to State) {
+			mtx.Lock()
+			transitions = append(transitions, transition{from, to})
+			mtx.Unlock()
+		},
+	})
+	cli := gentleman.New().URL(ts.URL).Use(b)
+	key := ts.Listener.Addr().String()
+
+	for i := 0; i < 2; i++ {
+		res, err := cli.Request().Send()
+		utils.Equal(t, err, nil)
+		utils.Equal(t, res.StatusCode, 503)
+	}
+	utils.Equal(t, b.State(key), Open)
+
+	_, err := cli.Request().Send()
+	utils.Equal(t, errors.Is(err, ErrOpen), true)
+	var openErr *OpenError
+	utils.Equal(t, errors.As(err, &openErr), true)
+	utils.Equal(t, openErr.Key, key)
+	utils.Equal(t, openErr.State, Open)
+	utils.Equal(t, openErr.RetryAfter > 0, true)
+
+	// Failed probe opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	_, err = cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, b.State(key), Open)
+
+	// Successful probe closes the circuit
+	atomic.StoreInt32(&fail, 0)
+	time.Sleep(60 * time.Millisecond)
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, b.State(key), Closed)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	utils.Equal(t, transitions, []transition{
+		{Closed, Open},
+		{Open, HalfOpen},
+		{HalfOpen, Open},
+		{Open, HalfOpen},
+		{HalfOpen, Closed},
+	})
+}
+
+func TestBreakerFailureRatio(t *testing.T) {
+	fail := int32(0)
+	ts := newServer(&fail)
+	defer ts.Close()
+
+	b := New(Options{FailureRatio: 0.5, MinRequests: 4})
+	cli := gentleman.New().URL(ts.URL).Use(b)
+	key := ts.Listener.Addr().String()
+
+	cli.Request().Send()
+	cli.Request().Send()
+	atomic.StoreInt32(&fail, 1)
+	cli.Request().Send()
+	utils.Equal(t, b.State(key), Closed)
+
+	cli.Request().Send()
+	utils.Equal(t, b.State(key), Open)
+
+	b.Reset(key)
+	utils.Equal(t, b.State(key), Closed)
+}
+
+func TestBreakerHalfOpenProbes(t *testing.T) {
+	b := New(Options{ConsecutiveFailures: 1, OpenTimeout: time.Millisecond})
+	now := time.Now()
+
+	gen, err := b.allow("foo", now)
+	utils.Equal(t, err, nil)
+	b.done("foo", gen, true, false, now)
+	utils.Equal(t, b.State("foo"), Open)
+
+	later := now.Add(time.Second)
+	gen, err = b.allow("foo", later)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, b.State("foo"), HalfOpen)
+
+	// Only one probe is allowed at a time
+	_, err = b.allow("foo", later)
+	var openErr *OpenError
+	utils.Equal(t, errors.As(err, &openErr), true)
+	utils.Equal(t, openErr.State, HalfOpen)
+
+	// Canceled probes release the slot
+	b.done("foo", gen, true, true, later)
+	gen, err = b.allow("foo", later)
+	utils.Equal(t, err, nil)
+
+	// Outcomes of previous generations are ignored
+	b.done("foo", gen-1, true, false, later)
+	utils.Equal(t, b.State("foo"), HalfOpen)
+
+	b.done("foo", gen, false, false, later)
+	utils.Equal(t, b.State("foo"), Closed)
+}
+
+func TestBreakerHalfOpenRelease(t *testing.T) {
+	fail := int32(1)
+	ts := newServer(&fail)
+	defer ts.Close()
+
+	b := New(Options{ConsecutiveFailures: 1, OpenTimeout: time.Millisecond})
+	cli := gentleman.New().URL(ts.URL).Use(b)
+	key := ts.Listener.Addr().String()
+
+	cli.Request().Send()
+	utils.Equal(t, b.State(key), Open)
+	time.Sleep(5 * time.Millisecond)
+
+	// Stopped probes release the slot
+	req := cli.Request()
+	req.UseHandler("before dial", func(ctx *c.Context, h c.Handler) {
+		h.Stop(ctx)
+	})
+	req.Send()
+	utils.Equal(t, b.State(key), HalfOpen)
+
+	// Intercepted probes release the slot without recording the outcome
+	req = cli.Request()
+	req.UseHandler("before dial", func(ctx *c.Context, h c.Handler) {
+		ctx.Response.StatusCode = 503
+		h.Next(ctx)
+	})
+	req.Send()
+	utils.Equal(t, b.State(key), HalfOpen)
+
+	atomic.StoreInt32(&fail, 0)
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, b.State(key), Closed)
+}
+
+func TestBreakerLocalErrors(t *testing.T) {
+	fail := int32(0)
+	ts := newServer(&fail)
+	defer ts.Close()
+
+	b := New(Options{ConsecutiveFailures: 1})
+	cli := gentleman.New().URL(ts.URL).Use(b)
+	key := ts.Listener.Addr().String()
+
+	// Errors raised before dialing are not recorded
+	req := cli.Request()
+	req.UseHandler("before dial", func(ctx *c.Context, h c.Handler) {
+		h.Error(ctx, errors.New("local error"))
+	})
+	_, err := req.Send()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, b.State(key), Closed)
+
+	// Caller cancellations are not recorded
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cli.Request().DoContext(ctx)
+	utils.Equal(t, errors.Is(err, context.Canceled), true)
+	utils.Equal(t, b.State(key), Closed)
+
+	// Dial errors are recorded
+	_, err = gentleman.New().URL("http://127.0.0.1:1").Use(b).Request().Send()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, b.State("127.0.0.1:1"), Open)
+}
+
+func TestBreakerRoute(t *testing.T) {
+	fail := int32(1)
+	ts := newServer(&fail)
+	defer ts.Close()
+
+	b := New(Options{ConsecutiveFailures: 1, Key: Route("users", mux.Path("/users"))})
+	cli := gentleman.New().URL(ts.URL).Use(b)
+
+	cli.Request().Path("/users").Send()
+	utils.Equal(t, b.State("users"), Open)
+
+	_, err := cli.Request().Path("/users").Send()
+	utils.Equal(t, errors.Is(err, ErrOpen), true)
+
+	res, err := cli.Request().Path("/groups").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 503)
+}
+
+func TestBreakerTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	b := New(Options{ConsecutiveFailures: 1, Failure: Timeout()})
+	cli := gentleman.New().URL(ts.URL).Use(b).Use(timeout.Request(10 * time.Millisecond))
+
+	_, err := cli.Request().Send()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, b.State(ts.Listener.Addr().String()), Open)
+}
+
+func TestWindow(t *testing.T) {
+	w := &window{size: time.Second}
+	now := time.Now()
+
+	w.add(now, true)
+	w.add(now.Add(500*time.Millisecond), false)
+	total, failures := w.counts(now.Add(500 * time.Millisecond))
+	utils.Equal(t, total, 2)
+	utils.Equal(t, failures, 1)
+
+	total, failures = w.counts(now.Add(1200 * time.Millisecond))
+	utils.Equal(t, total, 1)
+	utils.Equal(t, failures, 0)
+
+	w.reset()
+	total, _ = w.counts(now)
+	utils.Equal(t, total, 0)
+}
@@ -0,0 +1,55 @@
+package breaker
+
+import "time"
+
+// windowBuckets defines the number of buckets of the sliding window.
+const windowBuckets = 10
+
+// bucket stores the outcome counters of a window time slot.
+type bucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// window implements a sliding window of request outcomes
+// divided in fixed-size time buckets.
+type window struct {
+	size    time.Duration
+	buckets [windowBuckets]bucket
+}
+
+// add records a request outcome at the given time.
+func (w *window) add(now time.Time, failed bool) {
+	slot := w.size / windowBuckets
+	if slot <= 0 {
+		slot = 1
+	}
+
+	start := now.Truncate(slot)
+	b := &w.buckets[int(start.UnixNano()/int64(slot))%windowBuckets]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+
+	b.total++
+	if failed {
+		b.failures++
+	}
+}
+
+// counts returns the total and failed requests within the window at the given time.
+func (w *window) counts(now time.Time) (total, failures int) {
+	for _, b := range w.buckets {
+		if now.Sub(b.start) < w.size {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return total, failures
+}
+
+// reset clears all the recorded outcomes.
+func (w *window) reset() {
+	w.buckets = [windowBuckets]bucket{}
+}
@@ -0,0 +1,389 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// MaxEntrySize defines the maximum response body size in bytes to be cached.
+// Responses with bigger bodies are not stored.
+var MaxEntrySize int64 = 10 * 1024 * 1024
+
+// StatusKey defines the context store key used to expose the cache status
+// of the HTTP transaction. See Status.
+const StatusKey = "$cache"
+
+const (
+	// StatusMiss is used when the response was not served from the cache.
+	StatusMiss = "miss"
+
+	// StatusHit is used when a fresh response was served from the cache.
+	StatusHit = "hit"
+
+	// StatusStale is used when a stale response was served from the cache,
+	// either via stale-while-revalidate or stale-if-error.
+	StatusStale = "stale"
+
+	// StatusRevalidated is used when a cached response was served
+	// after being successfully revalidated with the origin server.
+	StatusRevalidated = "revalidated"
+)
+
+// Context store keys used internally during the HTTP transaction.
+const (
+	entryKey = "$cache.entry"
+	timeKey  = "$cache.time"
+)
+
+// cache encapsulates the cache plugin state.
+type cache struct {
+	// store stores the cache storage backend
+	store Store
+
+	// mtx protects pending
+	mtx sync.Mutex
+
+	// pending stores the keys being revalidated in background
+	pending map[string]bool
+}
+
+// Status returns the cache status of the given HTTP transaction context,
+// or an empty string if the request was not handled by the cache.
+func Status(ctx *c.Context) string {
+	return ctx.GetString(StatusKey)
+}
+
+// New creates a new HTTP cache plugin backed by the given store,
+// following the RFC 9111 private cache semantics.
+// Fresh responses are served without dialing the server and stale responses
+// are conditionally revalidated via ETag and Last-Modified validators.
+// The stale-while-revalidate and stale-if-error extensions are supported.
+// If store is nil, an unbounded in-memory store is used.
+func New(store Store) p.Plugin {
+	if store == nil {
+		store = NewMemoryStore(0)
+	}
+
+	ch := &cache{store: store, pending: map[string]bool{}}
+
+	plu := p.New()
+	plu.SetHandler("before dial", ch.lookup)
+	plu.SetHandler("response", ch.update)
+	plu.SetHandler("error", ch.recover)
+	return plu
+}
+
+// lookup serves cached responses for the outgoing request, if possible,
+// otherwise prepares the request to be conditionally revalidated.
+func (ch *cache) lookup(ctx *c.Context, h c.Handler) {
+	ctx.Delete(entryKey)
+	ctx.Delete(StatusKey)
+
+	req := ctx.Request
+	if req.Method != http.MethodGet {
+		h.Next(ctx)
+		return
+	}
+
+	t := time.Now()
+	ctx.Set(timeKey, t)
+	ctx.Set(StatusKey, StatusMiss)
+
+	reqCC := parseCacheControl(req.Header)
+	if reqCC.has("no-store") {
+		h.Next(ctx)
+		return
+	}
+
+	key := cacheKey(req)
+	entry, ok := ch.store.Get(key)
+	if !ok || !entry.matches(req) {
+		h.Next(ctx)
+		return
+	}
+
+	if fresh(entry, req, t) {
+		serve(ctx, entry, StatusHit, t)
+		h.Next(ctx)
+		return
+	}
+
+	resCC := parseCacheControl(entry.Header)
+	if swr, ok := resCC.duration("stale-while-revalidate"); ok && entry.staleness(t) <= swr && !resCC.has("no-cache") && !reqCC.has("no-cache") {
+		ch.revalidate(ctx.Client, req, key, entry)
+		serve(ctx, entry, StatusStale, t)
+		h.Next(ctx)
+		return
+	}
+
+	// Conditional requests defined by the user are not handled by the cache
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		h.Next(ctx)
+		return
+	}
+
+	for name, values := range entry.validators() {
+		req.Header[name] = values
+	}
+	ctx.Set(entryKey, entry)
+	h.Next(ctx)
+}
+
+// update stores cacheable responses and handles revalidation responses.
+func (ch *cache) update(ctx *c.Context, h c.Handler) {
+	switch Status(ctx) {
+	case StatusHit, StatusStale:
+		h.Next(ctx)
+		return
+	}
+
+	req, res := ctx.Request, ctx.Response
+	key := cacheKey(req)
+	t := time.Now()
+
+	// Unsafe methods invalidate the cached response of the target URL
+	if !safeMethod(req.Method) {
+		if res.StatusCode < 400 {
+			ch.store.Delete(key)
+		}
+		h.Next(ctx)
+		return
+	}
+
+	reqTime, ok := ctx.Get(timeKey).(time.Time)
+	if !ok {
+		reqTime = t
+	}
+
+	if entry, ok := ctx.Get(entryKey).(*Entry); ok {
+		if res.StatusCode == http.StatusNotModified {
+			res.Body.Close()
+			entry = entry.revalidated(res, reqTime, t)
+			ch.store.Set(key, entry)
+			serve(ctx, entry, StatusRevalidated, t)
+			h.Next(ctx)
+			return
+		}
+
+		if res.StatusCode >= 500 && staleIfError(entry, req, t) {
+			res.Body.Close()
+			serve(ctx, entry, StatusStale, t)
+			h.Next(ctx)
+			return
+		}
+	}
+
+	ch.save(key, req, res, reqTime, t)
+	h.Next(ctx)
+}
+
+// recover serves a stale cached response on network or server errors,
+// if allowed by the stale-if-error directive.
+func (ch *cache) recover(ctx *c.Context, h c.Handler) {
+	entry, ok := ctx.Get(entryKey).(*Entry)
+	if !ok || ctx.Err() != nil || Status(ctx) != StatusMiss {
+		h.Next(ctx)
+		return
+	}
+
+	// Only network errors or server error responses are recovered
+	if code := ctx.Response.StatusCode; code != 0 && code < 500 {
+		h.Next(ctx)
+		return
+	}
+
+	t := time.Now()
+	if !staleIfError(entry, ctx.Request, t) {
+		h.Next(ctx)
+		return
+	}
+
+	ctx.Response.Body.Close()
+	ctx.Error = nil
+	serve(ctx, entry, StatusStale, t)
+	h.Next(ctx)
+}
+
+// revalidate revalidates the given entry in background,
+// only once at a time per cache key.
+func (ch *cache) revalidate(client *http.Client, req *http.Request, key string, entry *Entry) {
+	ch.mtx.Lock()
+	if ch.pending[key] {
+		ch.mtx.Unlock()
+		return
+	}
+	ch.pending[key] = true
+	ch.mtx.Unlock()
+
+	req = req.Clone(context.Background())
+	for name, values := range entry.validators() {
+		req.Header[name] = values
+	}
+
+	go func() {
+		defer func() {
+			ch.mtx.Lock()
+			delete(ch.pending, key)
+			ch.mtx.Unlock()
+		}()
+
+		reqTime := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			return
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode == http.StatusNotModified {
+			ch.store.Set(key, entry.revalidated(res, reqTime, time.Now()))
+			return
+		}
+		ch.save(key, req, res, reqTime, time.Now())
+	}()
+}
+
+// save stores the given response if cacheable, restoring the response body
+// to be consumed again.
+func (ch *cache) save(key string, req *http.Request, res *http.Response, reqTime, resTime time.Time) {
+	vary, ok := storable(req, res)
+	if !ok {
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, MaxEntrySize+1))
+	res.Body = &readCloser{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+	if err != nil || int64(len(body)) > MaxEntrySize {
+		return
+	}
+
+	ch.store.Set(key, &Entry{
+		URL:          req.URL.String(),
+		StatusCode:   res.StatusCode,
+		Status:       res.Status,
+		Header:       res.Header.Clone(),
+		Body:         body,
+		Vary:         vary,
+		RequestTime:  reqTime,
+		ResponseTime: resTime,
+	})
+}
+
+// serve replaces the context response with the given cached entry.
+// A non-zero response status code triggers the intercept middleware phase.
+func serve(ctx *c.Context, entry *Entry, status string, t time.Time) {
+	ctx.Response = entry.response(ctx.Request, t)
+	ctx.Set(StatusKey, status)
+}
+
+// fresh returns true if the given entry can be served without revalidation.
+func fresh(entry *Entry, req *http.Request, t time.Time) bool {
+	reqCC := parseCacheControl(req.Header)
+	resCC := parseCacheControl(entry.Header)
+
+	if reqCC.has("no-cache") || resCC.has("no-cache") {
+		return false
+	}
+	if len(reqCC) == 0 && req.Header.Get("Pragma") == "no-cache" {
+		return false
+	}
+
+	age, lifetime := entry.age(t), entry.lifetime()
+	if maxAge, ok := reqCC.duration("max-age"); ok && age > maxAge {
+		return false
+	}
+	if minFresh, ok := reqCC.duration("min-fresh"); ok && lifetime-age < minFresh {
+		return false
+	}
+	if age < lifetime {
+		return true
+	}
+
+	// Clients may explicitly accept stale responses
+	if !reqCC.has("max-stale") || resCC.has("must-revalidate") {
+		return false
+	}
+	if reqCC["max-stale"] == "" {
+		return true
+	}
+	maxStale, ok := reqCC.duration("max-stale")
+	return ok && age-lifetime <= maxStale
+}
+
+// staleIfError returns true if the given stale entry can be served on errors.
+func staleIfError(entry *Entry, req *http.Request, t time.Time) bool {
+	resCC := parseCacheControl(entry.Header)
+	if resCC.has("must-revalidate") || resCC.has("no-cache") {
+		return false
+	}
+
+	staleness := entry.staleness(t)
+	for _, cc := range []directives{parseCacheControl(req.Header), resCC} {
+		if limit, ok := cc.duration("stale-if-error"); ok && staleness <= limit {
+			return true
+		}
+	}
+	return false
+}
+
+// storable returns true if the given response can be stored in the cache,
+// along with the request headers selected by the response Vary header.
+func storable(req *http.Request, res *http.Response) (http.Header, bool) {
+	if req.Method != http.MethodGet || res.StatusCode == http.StatusPartialContent {
+		return nil, false
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	resCC := parseCacheControl(res.Header)
+	if reqCC.has("no-store") || resCC.has("no-store") {
+		return nil, false
+	}
+
+	explicit := resCC.has("max-age") || resCC.has("public") || resCC.has("private") || res.Header.Get("Expires") != ""
+	if !explicit && !heuristicStatus[res.StatusCode] {
+		return nil, false
+	}
+
+	vary := http.Header{}
+	for _, field := range res.Header["Vary"] {
+		for _, name := range strings.Split(field, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				vary[name] = req.Header[name]
+			}
+		}
+	}
+
+	return vary, true
+}
+
+// safeMethod returns true if the given HTTP method is defined as safe.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// cacheKey returns the cache key for the given request.
+func cacheKey(req *http.Request) string {
+	return req.URL.String()
+}
+
+// readCloser joins an io.Reader with an io.Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
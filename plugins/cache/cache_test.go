@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman"
+	"github.com/lytics/gentleman/utils"
+)
+
+func newServer(fn func(w http.ResponseWriter, r *http.Request, calls int32)) (*httptest.Server, *int32) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fn(w, r, atomic.AddInt32(&calls, 1))
+	}))
+	return ts, &calls
+}
+
+func TestCacheHit(t *testing.T) {
+	ts, calls := newServer(func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, "hello %d", n)
+	})
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(New(nil))
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, Status(res.Context), StatusMiss)
+	utils.Equal(t, res.String(), "hello 1")
+
+	res, err = cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, Status(res.Context), StatusHit)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "hello 1")
+	utils.Equal(t, res.Header.Get("Age"), "0")
+	utils.Equal(t, atomic.LoadInt32(calls), int32(1))
+
+	res, err = cli.Request().SetHeader("Cache-Control", "no-cache").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, Status(res.Context), StatusMiss)
+	utils.Equal(t, res.String(), "hello 2")
+}
+
+func TestCacheRevalidate(t *testing.T) {
+	ts, calls := newServer(func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(304)
+			return
+		}
+		fmt.Fprint(w, "hello")
+	})
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(New(nil))
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, Status(res.Context), StatusMiss)
+
+	res, err = cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, Status(res.Context), StatusRevalidated)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "hello")
+	utils.Equal(t, atomic.LoadInt32(calls), int32(2))
+}
+
+func TestCacheVary(t *testing.T) {
+	ts, calls := newServer(func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprint(w, r.Header.Get("Accept-Language"))
+	})
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(New(nil))
+
+	res, _ := cli.Request().SetHeader("Accept-Language", "en").Send()
+	utils.Equal(t, res.String(), "en")
+	res, _ = cli.Request().SetHeader("Accept-Language", "en").Send()
+	utils.Equal(t, Status(res.Context), StatusHit)
+	res, _ = cli.Request().SetHeader("Accept-Language", "es").Send()
+	utils.Equal(t, Status(res.Context), StatusMiss)
+	utils.Equal(t, res.String(), "es")
+	utils.Equal(t, atomic.LoadInt32(calls), int32(2))
+}
+
+func TestCacheNoStore(t *testing.T) {
+	ts, calls := newServer(func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.Header().Set("Cache-Control", "no-store")
+		fmt.Fprint(w, "hello")
+	})
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(New(nil))
+	cli.Request().Send()
+	res, _ := cli.Request().Send()
+	utils.Equal(t, Status(res.Context), StatusMiss)
+	utils.Equal(t, atomic.LoadInt32(calls), int32(2))
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	ts, calls := newServer(func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, "hello %d", n)
+	})
+	defer ts.Close()
+
+	store := NewMemoryStore(10)
+	cli := gentleman.New().URL(ts.URL).Use(New(store))
+
+	cli.Request().Send()
+	utils.Equal(t, store.Len(), 1)
+
+	res, _ := cli.Request().Method("POST").Send()
+	utils.Equal(t, Status(res.Context), "")
+	utils.Equal(t, store.Len(), 0)
+
+	res, _ = cli.Request().Send()
+	utils.Equal(t, res.String(), "hello 3")
+	utils.Equal(t, atomic.LoadInt32(calls), int32(3))
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	ts, calls := newServer(func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		fmt.Fprintf(w, "hello %d", n)
+	})
+	defer ts.Close()
+
+	store := NewMemoryStore(10)
+	cli := gentleman.New().URL(ts.URL).Use(New(store))
+
+	cli.Request().Send()
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, Status(res.Context), StatusStale)
+	utils.Equal(t, res.String(), "hello 1")
+
+	// Wait for the background revalidation
+	for i := 0; i < 100; i++ {
+		if entry, _ := store.Get(ts.URL); string(entry.Body) == "hello 2" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	res, _ = cli.Request().Send()
+	utils.Equal(t, res.String(), "hello 2")
+	utils.NotEqual(t, atomic.LoadInt32(calls), int32(1))
+}
+
+func TestCacheStaleIfError(t *testing.T) {
+	ts, _ := newServer(func(w http.ResponseWriter, r *http.Request, n int32) {
+		if n > 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0, stale-if-error=60")
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "hello")
+	})
+
+	cli := gentleman.New().URL(ts.URL).Use(New(nil))
+	cli.Request().Send()
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, Status(res.Context), StatusStale)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "hello")
+
+	// Network errors
+	ts.Close()
+	res, err = cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, Status(res.Context), StatusStale)
+	utils.Equal(t, res.String(), "hello")
+}
+
+func TestCacheMaxAgeRequest(t *testing.T) {
+	entry := &Entry{
+		Header:       http.Header{"Cache-Control": []string{"max-age=60"}},
+		RequestTime:  time.Now().Add(-30 * time.Second),
+		ResponseTime: time.Now().Add(-30 * time.Second),
+	}
+
+	req, _ := http.NewRequest("GET", "http://foo", nil)
+	utils.Equal(t, fresh(entry, req, time.Now()), true)
+
+	req.Header.Set("Cache-Control", "max-age=10")
+	utils.Equal(t, fresh(entry, req, time.Now()), false)
+
+	req.Header.Set("Cache-Control", "min-fresh=40")
+	utils.Equal(t, fresh(entry, req, time.Now()), false)
+
+	utils.Equal(t, fresh(entry, req, time.Now().Add(time.Minute)), false)
+	req.Header.Set("Cache-Control", "max-stale=60")
+	utils.Equal(t, fresh(entry, req, time.Now().Add(time.Minute)), true)
+}
@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// heuristicStatus stores the status codes that are heuristically cacheable.
+var heuristicStatus = map[int]bool{
+	200: true, 203: true, 204: true, 206: true, 300: true, 301: true,
+	308: true, 404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// Entry represents a cached HTTP response.
+type Entry struct {
+	// URL stores the request URL.
+	URL string
+
+	// StatusCode stores the response status code.
+	StatusCode int
+
+	// Status stores the response status line text.
+	Status string
+
+	// Header stores the response headers.
+	Header http.Header
+
+	// Body stores the response body.
+	Body []byte
+
+	// Vary stores the request header values selected by the response Vary header.
+	Vary http.Header
+
+	// RequestTime stores when the request that originated the response was sent.
+	RequestTime time.Time
+
+	// ResponseTime stores when the response was received.
+	ResponseTime time.Time
+}
+
+// response creates a new http.Response based on the cached entry.
+func (e *Entry) response(req *http.Request, now time.Time) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(e.age(now)/time.Second)))
+
+	return &http.Response{
+		Status:        e.Status,
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// matches returns true if the given request matches the entry selected headers.
+func (e *Entry) matches(req *http.Request) bool {
+	for name, values := range e.Vary {
+		if strings.Join(req.Header[name], ", ") != strings.Join(values, ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+// date returns the response Date header value, or the response time if not present.
+func (e *Entry) date() time.Time {
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return date
+	}
+	return e.ResponseTime
+}
+
+// lifetime calculates the freshness lifetime of the entry.
+func (e *Entry) lifetime() time.Duration {
+	cc := parseCacheControl(e.Header)
+	if maxAge, ok := cc.duration("max-age"); ok {
+		return maxAge
+	}
+
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(e.date())
+	}
+
+	// Heuristic freshness based on the Last-Modified header
+	if modified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil && heuristicStatus[e.StatusCode] {
+		if lifetime := e.date().Sub(modified) / 10; lifetime > 0 {
+			return lifetime
+		}
+	}
+
+	return 0
+}
+
+// age calculates the current age of the entry.
+func (e *Entry) age(now time.Time) time.Duration {
+	apparent := e.ResponseTime.Sub(e.date())
+	if apparent < 0 {
+		apparent = 0
+	}
+
+	var value time.Duration
+	if seconds, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil {
+		value = time.Duration(seconds) * time.Second
+	}
+
+	corrected := value + e.ResponseTime.Sub(e.RequestTime)
+	if corrected > apparent {
+		apparent = corrected
+	}
+
+	return apparent + now.Sub(e.ResponseTime)
+}
+
+// staleness returns how long the entry has been stale, or a negative duration if fresh.
+func (e *Entry) staleness(now time.Time) time.Duration {
+	return e.age(now) - e.lifetime()
+}
+
+// validators returns the conditional request headers based on the entry validators.
+func (e *Entry) validators() http.Header {
+	header := http.Header{}
+	if etag := e.Header.Get("ETag"); etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	if modified := e.Header.Get("Last-Modified"); modified != "" {
+		header.Set("If-Modified-Since", modified)
+	}
+	return header
+}
+
+// revalidated creates a new entry updated with the given not modified response headers.
+func (e *Entry) revalidated(res *http.Response, requestTime, responseTime time.Time) *Entry {
+	entry := *e
+	entry.Header = e.Header.Clone()
+	for name, values := range res.Header {
+		switch http.CanonicalHeaderKey(name) {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			continue
+		}
+		entry.Header[name] = values
+	}
+	if res.Header.Get("Age") == "" {
+		entry.Header.Del("Age")
+	}
+	entry.RequestTime = requestTime
+	entry.ResponseTime = responseTime
+	return &entry
+}
+
+// directives represents the parsed Cache-Control header directives.
+type directives map[string]string
+
+// parseCacheControl parses the Cache-Control header directives.
+func parseCacheControl(header http.Header) directives {
+	cc := directives{}
+	for _, field := range header["Cache-Control"] {
+		for _, directive := range strings.Split(field, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, value := directive, ""
+			if i := strings.Index(directive, "="); i >= 0 {
+				name, value = directive[:i], strings.Trim(directive[i+1:], `"`)
+			}
+			cc[strings.ToLower(name)] = value
+		}
+	}
+	return cc
+}
+
+// has returns true if the given directive is present.
+func (d directives) has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
+
+// duration returns the given directive value in seconds as time.Duration.
+func (d directives) duration(name string) (time.Duration, bool) {
+	value, ok := d[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestEntryLifetime(t *testing.T) {
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	entry := &Entry{StatusCode: 200, Header: http.Header{}}
+	entry.Header.Set("Date", date.Format(http.TimeFormat))
+	utils.Equal(t, entry.lifetime(), time.Duration(0))
+
+	entry.Header.Set("Last-Modified", date.Add(-100*time.Hour).Format(http.TimeFormat))
+	utils.Equal(t, entry.lifetime(), 10*time.Hour)
+
+	entry.Header.Set("Expires", date.Add(time.Hour).Format(http.TimeFormat))
+	utils.Equal(t, entry.lifetime(), time.Hour)
+
+	entry.Header.Set("Cache-Control", "public, max-age=30")
+	utils.Equal(t, entry.lifetime(), 30*time.Second)
+}
+
+func TestEntryAge(t *testing.T) {
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	entry := &Entry{
+		Header:       http.Header{},
+		RequestTime:  date,
+		ResponseTime: date.Add(2 * time.Second),
+	}
+	entry.Header.Set("Date", date.Format(http.TimeFormat))
+	entry.Header.Set("Age", "10")
+
+	utils.Equal(t, entry.age(date.Add(2*time.Second)), 12*time.Second)
+	utils.Equal(t, entry.age(date.Add(10*time.Second)), 20*time.Second)
+}
+
+func TestEntryRevalidated(t *testing.T) {
+	entry := &Entry{Header: http.Header{}, Body: []byte("foo")}
+	entry.Header.Set("ETag", `"v1"`)
+	entry.Header.Set("Content-Length", "3")
+	entry.Header.Set("Age", "100")
+
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("ETag", `"v2"`)
+	res.Header.Set("Content-Length", "0")
+
+	updated := entry.revalidated(res, time.Now(), time.Now())
+	utils.Equal(t, updated.Header.Get("ETag"), `"v2"`)
+	utils.Equal(t, updated.Header.Get("Content-Length"), "3")
+	utils.Equal(t, updated.Header.Get("Age"), "")
+	utils.Equal(t, string(updated.Body), "foo")
+	utils.Equal(t, entry.Header.Get("ETag"), `"v1"`)
+}
+
+func TestParseCacheControl(t *testing.T) {
+	header := http.Header{}
+	header.Add("Cache-Control", `Max-Age=10, no-cache`)
+	header.Add("Cache-Control", `stale-if-error="20"`)
+
+	cc := parseCacheControl(header)
+	utils.Equal(t, cc.has("no-cache"), true)
+	utils.Equal(t, cc.has("no-store"), false)
+
+	maxAge, ok := cc.duration("max-age")
+	utils.Equal(t, ok, true)
+	utils.Equal(t, maxAge, 10*time.Second)
+
+	sie, _ := cc.duration("stale-if-error")
+	utils.Equal(t, sie, 20*time.Second)
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store represents the interface implemented by cache storage backends.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Get retrieves the cached entry for the given key, if present.
+	Get(key string) (*Entry, bool)
+
+	// Set stores the given entry by key, replacing any existent one.
+	Set(key string, entry *Entry)
+
+	// Delete removes the cached entry for the given key.
+	Delete(key string)
+}
+
+// MemoryStore implements an in-memory Store with least recently used eviction.
+type MemoryStore struct {
+	// mtx protects the fields below
+	mtx sync.Mutex
+
+	// capacity stores the maximum number of entries
+	capacity int
+
+	// items stores the list elements by key
+	items map[string]*list.Element
+
+	// order stores the entries ordered by usage, most recent first
+	order *list.List
+}
+
+// memoryItem represents a MemoryStore list element value.
+type memoryItem struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemoryStore creates a new in-memory LRU store holding up to
+// the given number of entries. Zero or negative capacity means no limit.
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Get implements the Store interface.
+func (s *MemoryStore) Get(key string) (*Entry, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*memoryItem).entry, true
+}
+
+// Set implements the Store interface.
+func (s *MemoryStore) Set(key string, entry *Entry) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		elem.Value.(*memoryItem).entry = entry
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	s.items[key] = s.order.PushFront(&memoryItem{key, entry})
+	if s.capacity > 0 && s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryItem).key)
+	}
+}
+
+// Delete implements the Store interface.
+func (s *MemoryStore) Delete(key string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.order.Remove(elem)
+		delete(s.items, key)
+	}
+}
+
+// Len returns the number of stored entries.
+func (s *MemoryStore) Len() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.order.Len()
+}
+
+// DiskStore implements a Store persisting entries as JSON files in a directory.
+type DiskStore struct {
+	// dir stores the cache directory path
+	dir string
+}
+
+// NewDiskStore creates a new disk store using the given directory,
+// creating it if it does not exist.
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskStore{dir: dir}, nil
+}
+
+// Get implements the Store interface.
+func (s *DiskStore) Get(key string) (*Entry, bool) {
+	buf, err := ioutil.ReadFile(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	entry := &Entry{}
+	if err := json.Unmarshal(buf, entry); err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set implements the Store interface.
+// Entries are written into a temporary file and then renamed,
+// so concurrent readers never observe partial entries.
+func (s *DiskStore) Set(key string, entry *Entry) {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	file, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+
+	_, err = file.Write(buf)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), s.path(key))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+}
+
+// Delete implements the Store interface.
+func (s *DiskStore) Delete(key string) {
+	os.Remove(s.path(key))
+}
+
+// path returns the file path for the given key.
+func (s *DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(2)
+	store.Set("foo", &Entry{URL: "foo"})
+	store.Set("bar", &Entry{URL: "bar"})
+
+	// Mark foo as recently used
+	_, ok := store.Get("foo")
+	utils.Equal(t, ok, true)
+
+	store.Set("baz", &Entry{URL: "baz"})
+	utils.Equal(t, store.Len(), 2)
+
+	_, ok = store.Get("bar")
+	utils.Equal(t, ok, false)
+
+	entry, ok := store.Get("foo")
+	utils.Equal(t, ok, true)
+	utils.Equal(t, entry.URL, "foo")
+
+	store.Delete("foo")
+	_, ok = store.Get("foo")
+	utils.Equal(t, ok, false)
+	utils.Equal(t, store.Len(), 1)
+}
+
+func TestDiskStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gentleman-cache")
+	utils.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	store, err := NewDiskStore(dir)
+	utils.Equal(t, err, nil)
+
+	_, ok := store.Get("http://foo")
+	utils.Equal(t, ok, false)
+
+	store.Set("http://foo", &Entry{
+		URL:        "http://foo",
+		StatusCode: 200,
+		Header:     http.Header{"Etag": []string{`"v1"`}},
+		Body:       []byte("hello"),
+	})
+
+	entry, ok := store.Get("http://foo")
+	utils.Equal(t, ok, true)
+	utils.Equal(t, entry.StatusCode, 200)
+	utils.Equal(t, entry.Header.Get("ETag"), `"v1"`)
+	utils.Equal(t, string(entry.Body), "hello")
+
+	files, _ := ioutil.ReadDir(dir)
+	utils.Equal(t, len(files), 1)
+
+	store.Delete("http://foo")
+	_, ok = store.Get("http://foo")
+	utils.Equal(t, ok, false)
+}
@@ -0,0 +1,353 @@
+package har
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lytics/gentleman"
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// Version defines the HAR format version.
+const Version = "1.2"
+
+// DefaultMaxBodySize defines the default maximum number of body bytes captured per entry.
+const DefaultMaxBodySize = 1 << 20
+
+// Options represents the HAR archive plugin options.
+type Options struct {
+	// MaxBodySize defines the maximum number of request and response body bytes
+	// captured per entry. Bigger bodies are truncated and flagged with a comment.
+	// Defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+}
+
+// Archive captures the HTTP exchanges as HAR entries.
+// Every round trip is captured, including redirects and retry attempts,
+// as long as the plugin is registered before the retry plugin.
+// Failed round trips are captured with a zero response status
+// and the error as comment.
+//
+// Bodies are captured while they are read, without buffering them ahead
+// of the consumer, so entries of streamed responses contain the bytes read so far.
+type Archive struct {
+	p.Plugin
+
+	// opts stores the archive options
+	opts Options
+
+	// mtx protects records and their captured bodies
+	mtx sync.Mutex
+
+	// records stores the captured exchanges
+	records []*record
+}
+
+// New creates a new HAR archive plugin with the given options.
+// The plugin should be used at client level to capture every exchange of the client.
+func New(opts Options) *Archive {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+
+	a := &Archive{opts: opts}
+	a.Plugin = p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		ctx.Client.Transport = &Transport{a, ctx.Client.Transport}
+		h.Next(ctx)
+	})
+	return a
+}
+
+// HAR returns the HTTP archive of the captured entries.
+func (a *Archive) HAR() *HAR {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	entries := make([]*Entry, len(a.records))
+	for i, rec := range a.records {
+		entries[i] = rec.build()
+	}
+	return &HAR{Log: &Log{
+		Version: Version,
+		Creator: &Creator{Name: "gentleman", Version: gentleman.Version},
+		Entries: entries,
+	}}
+}
+
+// Entries returns the number of captured entries.
+func (a *Archive) Entries() int {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return len(a.records)
+}
+
+// Reset removes the captured entries.
+func (a *Archive) Reset() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.records = nil
+}
+
+// WriteTo writes the HTTP archive as JSON into the given writer.
+func (a *Archive) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(a.HAR(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// WriteFile writes the HTTP archive as JSON into the given file.
+func (a *Archive) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := a.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// add appends the given record.
+func (a *Archive) add(rec *record) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.records = append(a.records, rec)
+}
+
+// write captures the given body bytes, up to MaxBodySize.
+func (a *Archive) write(body *capture, data []byte) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	body.size += int64(len(data))
+	if room := a.opts.MaxBodySize - int64(len(body.data)); int64(len(data)) > room {
+		data = data[:room]
+		body.truncated = true
+	}
+	body.data = append(body.data, data...)
+}
+
+// done records the end of the given exchange, once its response body
+// is read until EOF or closed.
+func (a *Archive) done(rec *record) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	rec.end = time.Now()
+}
+
+// record represents a captured exchange, whose bodies are captured while read.
+type record struct {
+	entry *Entry
+	trace *timings
+	end   time.Time
+	req   *capture
+	res   *capture
+}
+
+// build creates the HAR entry of the record, including the bodies captured so far.
+func (r *record) build() *Entry {
+	entry := *r.entry
+	entry.Request = entry.Request.withBody(r.req)
+	entry.Response = entry.Response.withBody(r.res)
+	entry.Timings, entry.Time = r.trace.timings(r.end)
+	return &entry
+}
+
+// capture stores the first body bytes read, and the total number of bytes read.
+type capture struct {
+	data      []byte
+	size      int64
+	truncated bool
+}
+
+// captureBody captures the bytes read from the body into the archive.
+type captureBody struct {
+	io.ReadCloser
+	archive *Archive
+	capture *capture
+	once    sync.Once
+	done    func()
+}
+
+// Read implements the io.Reader interface.
+func (b *captureBody) Read(buf []byte) (int, error) {
+	n, err := b.ReadCloser.Read(buf)
+	if n > 0 {
+		b.archive.write(b.capture, buf[:n])
+	}
+	if err == io.EOF && b.done != nil {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (b *captureBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.done != nil {
+		b.once.Do(b.done)
+	}
+	return err
+}
+
+// Transport is the http.RoundTripper installed by the archive before dialing.
+// It archives every round trip passing through it, capturing the request and
+// response bodies as they are consumed by the underlying transport and the caller.
+// It never replaces the client transport itself: redirects pass through it as long
+// as outer transports, like the retry one, restore their inner transport once done.
+type Transport struct {
+	archive   *Archive
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the required method by http.RoundTripper interface.
+// The given request is not modified: the bodies are captured via a request copy
+// and the returned response body.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := &record{trace: &timings{start: time.Now()}, req: &capture{}, res: &capture{}}
+	rec.entry = &Entry{StartedDateTime: rec.trace.start, Request: newRequest(req)}
+
+	out := req.WithContext(httptrace.WithClientTrace(req.Context(), rec.trace.clientTrace()))
+	if req.Body != nil && req.Body != http.NoBody {
+		out.Body = &captureBody{ReadCloser: req.Body, archive: t.archive, capture: rec.req}
+	}
+
+	res, err := t.transport.RoundTrip(out)
+	rec.end = time.Now()
+	if err != nil {
+		rec.entry.Response = &Response{Cookies: []*Cookie{}, Headers: []*NameValue{}, Content: &Content{}, HeadersSize: -1, BodySize: -1}
+		rec.entry.Comment = err.Error()
+		t.archive.add(rec)
+		return nil, err
+	}
+
+	rec.entry.Response = newResponse(res)
+	rec.entry.ServerIPAddress, rec.entry.Connection = rec.trace.endpoints()
+	if res.Body != nil && res.Body != http.NoBody && !writable(res.Body) {
+		res.Body = &captureBody{ReadCloser: res.Body, archive: t.archive, capture: rec.res, done: func() { t.archive.done(rec) }}
+	}
+	t.archive.add(rec)
+	return res, nil
+}
+
+// writable reports whether the body is writable, as in upgraded connections,
+// in which case it must not be wrapped.
+func writable(body io.ReadCloser) bool {
+	_, ok := body.(io.Writer)
+	return ok
+}
+
+// timings collects the exchange timings using a client trace.
+type timings struct {
+	mtx        sync.Mutex
+	start      time.Time
+	dnsStart   time.Time
+	dnsDone    time.Time
+	connStart  time.Time
+	connDone   time.Time
+	tlsStart   time.Time
+	tlsDone    time.Time
+	gotConn    time.Time
+	wrote      time.Time
+	firstByte  time.Time
+	server     string
+	connection string
+}
+
+// clientTrace returns the client trace hooks recording the timings.
+func (t *timings) clientTrace() *httptrace.ClientTrace {
+	set := func(field *time.Time) {
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { set(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { set(&t.dnsDone) },
+		ConnectStart:         func(string, string) { set(&t.connStart) },
+		ConnectDone:          func(string, string, error) { set(&t.connDone) },
+		TLSHandshakeStart:    func() { set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { set(&t.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&t.wrote) },
+		GotFirstResponseByte: func() { set(&t.firstByte) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			set(&t.gotConn)
+			t.mtx.Lock()
+			defer t.mtx.Unlock()
+			if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				t.server = host
+			}
+			if _, port, err := net.SplitHostPort(info.Conn.LocalAddr().String()); err == nil {
+				t.connection = port
+			}
+		},
+	}
+}
+
+// endpoints returns the server IP address and the client connection port.
+func (t *timings) endpoints() (string, string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.server, t.connection
+}
+
+// timings returns the HAR timings and the total time for the given end time.
+func (t *timings) timings(end time.Time) (*Timings, float64) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	timings := &Timings{
+		Blocked: -1,
+		DNS:     between(t.dnsStart, t.dnsDone),
+		Connect: between(t.connStart, t.connDone),
+		SSL:     between(t.tlsStart, t.tlsDone),
+		Send:    between(t.gotConn, t.wrote),
+		Wait:    between(t.wrote, t.firstByte),
+		Receive: between(t.firstByte, end),
+	}
+	if t.tlsDone.After(t.connDone) && timings.Connect >= 0 {
+		// The connect time includes the TLS handshake
+		timings.Connect = between(t.connStart, t.tlsDone)
+	}
+
+	if !t.gotConn.IsZero() {
+		timings.Blocked = between(t.start, t.gotConn)
+		for _, spent := range []float64{timings.DNS, timings.Connect} {
+			if spent > 0 {
+				timings.Blocked -= spent
+			}
+		}
+		if timings.Blocked < 0 {
+			timings.Blocked = 0
+		}
+	}
+	return timings, milliseconds(end.Sub(t.start))
+}
+
+// between returns the milliseconds between the given times, or -1 if any is unknown.
+func between(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() {
+		return -1
+	}
+	return milliseconds(end.Sub(start))
+}
+
+// milliseconds returns the given duration in milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,295 @@
+// Package har implements a plugin capturing the HTTP exchanges
+// in the HTTP Archive (HAR) 1.2 format.
+// See: http://www.softwareishard.com/blog/har-12-spec/
+package har
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR represents the root object of a HTTP archive.
+type HAR struct {
+	Log *Log `json:"log"`
+}
+
+// Log represents the HTTP archive log.
+type Log struct {
+	Version string   `json:"version"`
+	Creator *Creator `json:"creator"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Creator represents the application creating the archive.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry represents an archived HTTP exchange.
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"`
+	Request         *Request  `json:"request"`
+	Response        *Response `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         *Timings  `json:"timings"`
+	ServerIPAddress string    `json:"serverIPAddress,omitempty"`
+	Connection      string    `json:"connection,omitempty"`
+	Comment         string    `json:"comment,omitempty"`
+}
+
+// Request represents an archived HTTP request.
+type Request struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []*Cookie    `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	QueryString []*NameValue `json:"queryString"`
+	PostData    *PostData    `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+// Response represents an archived HTTP response.
+type Response struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []*Cookie    `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	Content     *Content     `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+// Cookie represents an archived cookie.
+type Cookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+}
+
+// NameValue represents a header or query string parameter.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData represents the request body.
+// Truncated bodies are flagged with a comment.
+type PostData struct {
+	MimeType string   `json:"mimeType"`
+	Params   []*Param `json:"params,omitempty"`
+	Text     string   `json:"text"`
+	Comment  string   `json:"comment,omitempty"`
+}
+
+// Param represents a posted form parameter.
+type Param struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// Content represents the response body.
+// Binary content is base64 encoded and truncated content is flagged with a comment.
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Timings represents the exchange timings in milliseconds.
+// Non-applicable timings are -1.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// newRequest creates an archived request, without the body.
+func newRequest(req *http.Request) *Request {
+	r := &Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: protocol(req.Proto),
+		Cookies:     []*Cookie{},
+		QueryString: []*NameValue{},
+		HeadersSize: -1,
+	}
+
+	header := req.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if req.Host != "" {
+		header.Set("Host", req.Host)
+	} else {
+		header.Set("Host", req.URL.Host)
+	}
+	r.Headers = headers(header)
+
+	for _, cookie := range req.Cookies() {
+		r.Cookies = append(r.Cookies, &Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	r.QueryString = values(req.URL.Query())
+
+	if req.Body != nil && req.Body != http.NoBody {
+		r.PostData = &PostData{MimeType: req.Header.Get("Content-Type")}
+	}
+	return r
+}
+
+// withBody returns a copy of the request including the captured body.
+func (r *Request) withBody(body *capture) *Request {
+	req := *r
+	req.BodySize = int(body.size)
+	if r.PostData == nil {
+		return &req
+	}
+
+	data := *r.PostData
+	data.Text = text(body.data)
+	if body.truncated {
+		data.Comment = truncated(body)
+	} else if mediaType, _, _ := mime.ParseMediaType(data.MimeType); mediaType == "application/x-www-form-urlencoded" {
+		form, _ := url.ParseQuery(string(body.data))
+		for _, param := range values(form) {
+			data.Params = append(data.Params, &Param{param.Name, param.Value})
+		}
+	}
+	req.PostData = &data
+	return &req
+}
+
+// newResponse creates an archived response, without the body.
+func newResponse(res *http.Response) *Response {
+	r := &Response{
+		Status:      res.StatusCode,
+		StatusText:  http.StatusText(res.StatusCode),
+		HTTPVersion: protocol(res.Proto),
+		Cookies:     []*Cookie{},
+		Headers:     headers(res.Header),
+		Content:     &Content{MimeType: res.Header.Get("Content-Type")},
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	if res.Uncompressed {
+		r.BodySize = -1
+	}
+
+	for _, cookie := range res.Cookies() {
+		c := &Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			c.Expires = &cookie.Expires
+		}
+		r.Cookies = append(r.Cookies, c)
+	}
+	return r
+}
+
+// withBody returns a copy of the response including the captured body.
+func (r *Response) withBody(body *capture) *Response {
+	res := *r
+	if res.BodySize != -1 {
+		res.BodySize = int(body.size)
+	}
+
+	content := *r.Content
+	content.Size = int(body.size)
+	if data := validPrefix(body); data != nil {
+		content.Text = string(data)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body.data)
+		content.Encoding = "base64"
+	}
+	if body.truncated {
+		content.Comment = truncated(body)
+	}
+	res.Content = &content
+	return &res
+}
+
+// validPrefix returns the captured body if it is valid UTF-8, or nil otherwise.
+// Truncated bodies may end with an incomplete sequence, which is trimmed.
+func validPrefix(body *capture) []byte {
+	data := body.data
+	for i := 0; body.truncated && i < utf8.UTFMax-1 && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	if !utf8.Valid(data) {
+		return nil
+	}
+	return data
+}
+
+// truncated returns the comment of truncated bodies.
+func truncated(body *capture) string {
+	return fmt.Sprintf("body truncated to %d of %d bytes read", len(body.data), body.size)
+}
+
+// headers returns the given headers sorted by name.
+func headers(header http.Header) []*NameValue {
+	list := []*NameValue{}
+	for name, vals := range header {
+		for _, value := range vals {
+			list = append(list, &NameValue{name, value})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// values returns the given query or form values sorted by name.
+func values(vals url.Values) []*NameValue {
+	list := []*NameValue{}
+	for name, vs := range vals {
+		for _, value := range vs {
+			list = append(list, &NameValue{name, value})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// text returns the given body as text, replacing invalid UTF-8 sequences.
+func text(body []byte) string {
+	if utf8.Valid(body) {
+		return string(body)
+	}
+	return strings.ToValidUTF8(string(body), "�")
+}
+
+// protocol returns the HTTP protocol version, defaulting to HTTP/1.1.
+func protocol(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
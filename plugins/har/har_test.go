@@ -0,0 +1,186 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman"
+	"github.com/lytics/gentleman/plugins/retry"
+	"github.com/lytics/gentleman/plugins/retry/retrier"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestArchive(t *testing.T) {
+	var failures int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/target?b=2&a=1", http.StatusFound)
+		case "/flaky":
+			if atomic.AddInt32(&failures, 1) == 1 {
+				w.WriteHeader(503)
+				return
+			}
+			w.Write([]byte("recovered"))
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0, 255})
+		default:
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true, Expires: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)})
+			w.Header().Set("Content-Type", "text/plain")
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Write(append([]byte("ok "), body...))
+		}
+	}))
+	defer ts.Close()
+
+	archive := New(Options{})
+	cli := gentleman.New().URL(ts.URL).Use(archive)
+	cli.Use(retry.New(retrier.New(retrier.ConstantBackoff(2, time.Millisecond), nil), nil))
+
+	res, err := cli.Request().Path("/redirect").AddCookie(&http.Cookie{Name: "foo", Value: "bar"}).Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "ok ")
+	utils.Equal(t, archive.Entries(), 2)
+
+	res, err = cli.Request().Path("/flaky").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "recovered")
+	utils.Equal(t, archive.Entries(), 4)
+
+	res, err = cli.Request().Method("POST").Path("/form").BodyString("x=1&y=2").SetHeader("Content-Type", "application/x-www-form-urlencoded").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "ok x=1&y=2")
+	res, err = cli.Request().Path("/binary").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.Bytes(), []byte{0, 255})
+
+	log := archive.HAR().Log
+	utils.Equal(t, log.Version, "1.2")
+	utils.Equal(t, log.Creator.Version, gentleman.Version)
+	utils.Equal(t, len(log.Entries), 6)
+
+	redirect := log.Entries[0]
+	utils.Equal(t, redirect.Request.Method, "GET")
+	utils.Equal(t, redirect.Request.HTTPVersion, "HTTP/1.1")
+	utils.Equal(t, redirect.Request.Cookies[0].Name, "foo")
+	utils.Equal(t, redirect.Response.Status, 302)
+	utils.Equal(t, redirect.Response.RedirectURL, "/target?b=2&a=1")
+	utils.Equal(t, redirect.ServerIPAddress, "127.0.0.1")
+	utils.Equal(t, redirect.Timings.Wait >= 0, true)
+	utils.Equal(t, redirect.Time >= redirect.Timings.Wait, true)
+
+	target := log.Entries[1]
+	utils.Equal(t, target.Request.QueryString, []*NameValue{{"a", "1"}, {"b", "2"}})
+	utils.Equal(t, target.Response.Cookies[0].Name, "session")
+	utils.Equal(t, target.Response.Cookies[0].HTTPOnly, true)
+	utils.Equal(t, target.Response.Content.MimeType, "text/plain")
+	utils.Equal(t, target.Response.Content.Text, "ok ")
+
+	utils.Equal(t, log.Entries[2].Response.Status, 503)
+	utils.Equal(t, log.Entries[3].Response.Status, 200)
+
+	form := log.Entries[4]
+	utils.Equal(t, form.Request.BodySize, 7)
+	utils.Equal(t, form.Request.PostData.Text, "x=1&y=2")
+	utils.Equal(t, form.Request.PostData.Params, []*Param{{"x", "1"}, {"y", "2"}})
+	utils.Equal(t, form.Response.Content.Text, "ok x=1&y=2")
+
+	binary := log.Entries[5]
+	utils.Equal(t, binary.Response.Content.Encoding, "base64")
+	utils.Equal(t, binary.Response.Content.Text, "AP8=")
+	utils.Equal(t, binary.Response.Content.Size, 2)
+
+	buf := &bytes.Buffer{}
+	_, err = archive.WriteTo(buf)
+	utils.Equal(t, err, nil)
+	decoded := &HAR{}
+	utils.Equal(t, json.Unmarshal(buf.Bytes(), decoded), nil)
+	utils.Equal(t, len(decoded.Log.Entries), 6)
+
+	archive.Reset()
+	utils.Equal(t, archive.Entries(), 0)
+}
+
+func TestArchiveError(t *testing.T) {
+	archive := New(Options{})
+	_, err := gentleman.New().URL("http://127.0.0.1:1").Use(archive).Request().Send()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, archive.Entries(), 1)
+
+	entry := archive.HAR().Log.Entries[0]
+	utils.Equal(t, entry.Response.Status, 0)
+	utils.NotEqual(t, entry.Comment, "")
+
+	dir, _ := ioutil.TempDir("", "har")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "client.har")
+	utils.Equal(t, archive.WriteFile(path), nil)
+	data, _ := ioutil.ReadFile(path)
+	utils.Equal(t, json.Valid(data), true)
+}
+
+func TestArchiveTruncated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(append([]byte("abcdé"), body...))
+	}))
+	defer ts.Close()
+
+	archive := New(Options{MaxBodySize: 5})
+	cli := gentleman.New().URL(ts.URL).Use(archive)
+	res, err := cli.Request().Method("POST").BodyString("x=1&y=2").SetHeader("Content-Type", "application/x-www-form-urlencoded").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "abcdéx=1&y=2")
+
+	entry := archive.HAR().Log.Entries[0]
+	utils.Equal(t, entry.Request.BodySize, 7)
+	utils.Equal(t, entry.Request.PostData.Text, "x=1&y")
+	utils.Equal(t, entry.Request.PostData.Params, []*Param(nil))
+	utils.Equal(t, entry.Request.PostData.Comment, "body truncated to 5 of 7 bytes read")
+
+	// The incomplete UTF-8 sequence is trimmed
+	utils.Equal(t, entry.Response.Content.Size, 13)
+	utils.Equal(t, entry.Response.Content.Text, "abcd")
+	utils.Equal(t, entry.Response.Content.Encoding, "")
+	utils.Equal(t, entry.Response.Content.Comment, "body truncated to 5 of 13 bytes read")
+}
+
+func TestArchiveStreaming(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(" world"))
+	}))
+	defer ts.Close()
+
+	archive := New(Options{})
+	res, err := gentleman.New().URL(ts.URL).Use(archive).Request().Send()
+	utils.Equal(t, err, nil)
+
+	// The response body is captured as it is consumed
+	utils.Equal(t, archive.Entries(), 1)
+	utils.Equal(t, archive.HAR().Log.Entries[0].Response.Content.Size, 0)
+
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(res, buf)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, archive.HAR().Log.Entries[0].Response.Content.Text, "hello")
+
+	rest, _ := ioutil.ReadAll(res)
+	utils.Equal(t, string(rest), " world")
+	res.Close()
+	entry := archive.HAR().Log.Entries[0]
+	utils.Equal(t, entry.Response.Content.Text, "hello world")
+	utils.Equal(t, entry.Response.BodySize, 11)
+	utils.Equal(t, entry.Timings.Receive >= 0, true)
+}
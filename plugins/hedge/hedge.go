@@ -0,0 +1,273 @@
+package hedge
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+const (
+	// HedgeDelay defines the default time to wait for a response
+	// before firing a hedged attempt.
+	HedgeDelay = 100 * time.Millisecond
+
+	// MinSamples defines the minimum number of observed latencies
+	// required to derive the hedging delay from a percentile.
+	MinSamples = 10
+
+	// maxSamples defines the number of observed latencies kept.
+	maxSamples = 128
+)
+
+const (
+	// WinnerKey defines the context store key used to expose the index of the
+	// attempt that won the race, zero being the original attempt.
+	WinnerKey = "$hedge.winner"
+
+	// AttemptsKey defines the context store key used to expose
+	// the number of attempts fired, including the original one.
+	AttemptsKey = "$hedge.attempts"
+)
+
+// Idempotent stores the HTTP methods hedged by default.
+var Idempotent = []string{"GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE"}
+
+// Options represents the hedging plugin options.
+type Options struct {
+	// Delay defines the time to wait for a response before firing a hedged attempt.
+	// Used until enough latencies are observed if Percentile is defined.
+	// Defaults to HedgeDelay.
+	Delay time.Duration
+
+	// Percentile defines the observed latency percentile, between 0 and 1,
+	// used as hedging delay, such as 0.95. Zero disables it.
+	Percentile float64
+
+	// MaxHedges defines the maximum number of hedged attempts. Defaults to 1.
+	MaxHedges int
+
+	// Methods defines the HTTP methods to hedge. Defaults to Idempotent.
+	Methods []string
+}
+
+// Hedger fires duplicate attempts of slow requests, returning the response
+// of whichever attempt finishes first and canceling the rest.
+// It implements the plugin interface.
+type Hedger struct {
+	p.Plugin
+
+	// opts stores the hedging options
+	opts Options
+
+	// mtx protects samples
+	mtx sync.Mutex
+
+	// samples stores the last observed latencies
+	samples []time.Duration
+
+	// next stores the next sample index to be replaced
+	next int
+}
+
+// New creates a new hedging plugin with the given options.
+func New(opts Options) *Hedger {
+	if opts.Delay <= 0 {
+		opts.Delay = HedgeDelay
+	}
+	if opts.MaxHedges < 1 {
+		opts.MaxHedges = 1
+	}
+	if opts.Methods == nil {
+		opts.Methods = Idempotent
+	}
+
+	hg := &Hedger{opts: opts}
+	hg.Plugin = p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		if hg.hedgeable(ctx.Request.Method) {
+			ctx.Client.Transport = &Transport{hg, ctx.Client.Transport, ctx}
+		}
+		h.Next(ctx)
+	})
+	return hg
+}
+
+// Delay returns the current hedging delay.
+func (hg *Hedger) Delay() time.Duration {
+	if hg.opts.Percentile <= 0 {
+		return hg.opts.Delay
+	}
+
+	hg.mtx.Lock()
+	defer hg.mtx.Unlock()
+
+	if len(hg.samples) < MinSamples {
+		return hg.opts.Delay
+	}
+
+	sorted := make([]time.Duration, len(hg.samples))
+	copy(sorted, hg.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	index := int(hg.opts.Percentile*float64(len(sorted))+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
+
+// observe records the given response latency.
+func (hg *Hedger) observe(latency time.Duration) {
+	hg.mtx.Lock()
+	defer hg.mtx.Unlock()
+
+	if len(hg.samples) < maxSamples {
+		hg.samples = append(hg.samples, latency)
+		return
+	}
+	hg.samples[hg.next] = latency
+	hg.next = (hg.next + 1) % maxSamples
+}
+
+// hedgeable returns true if the given HTTP method can be hedged.
+func (hg *Hedger) hedgeable(method string) bool {
+	for _, m := range hg.opts.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Transport provides a http.RoundTripper compatible transport who encapsulates
+// the original http.Transport and provides hedged requests support.
+type Transport struct {
+	hedger    *Hedger
+	transport http.RoundTripper
+	context   *c.Context
+}
+
+// result represents the outcome of an attempt.
+type result struct {
+	index   int
+	res     *http.Response
+	err     error
+	latency time.Duration
+}
+
+// RoundTrip implements the required method by http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Restore original http.Transport
+	t.context.Client.Transport = t.transport
+
+	// Cache the body buffer to be sent by every attempt
+	var buf []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if buf, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	attempts := t.hedger.opts.MaxHedges + 1
+	results := make(chan result, attempts)
+	cancels := make([]context.CancelFunc, 0, attempts)
+
+	launch := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		cancels = append(cancels, cancel)
+
+		reqCopy := req.WithContext(ctx)
+		if buf != nil {
+			reqCopy.Body = ioutil.NopCloser(bytes.NewReader(buf))
+		}
+
+		index, start := len(cancels)-1, time.Now()
+		go func() {
+			res, err := t.transport.RoundTrip(reqCopy)
+			results <- result{index, res, err, time.Since(start)}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(t.hedger.Delay())
+	defer timer.Stop()
+
+	var lastErr error
+	for pending := 1; pending > 0; {
+		select {
+		case r := <-results:
+			pending--
+			if r.err != nil {
+				lastErr = r.err
+				// Fire the next attempt right away if there are no pending ones
+				if pending == 0 && len(cancels) < attempts && req.Context().Err() == nil {
+					launch()
+					pending++
+				}
+				continue
+			}
+
+			t.hedger.observe(r.latency)
+			t.context.Set(WinnerKey, r.index)
+			t.context.Set(AttemptsKey, len(cancels))
+
+			// Cancel the losers and discard their responses
+			for i, cancel := range cancels {
+				if i != r.index {
+					cancel()
+				}
+			}
+			go discard(results, pending)
+
+			r.res.Body = &cancelBody{r.res.Body, cancels[r.index]}
+			return r.res, nil
+
+		case <-timer.C:
+			if len(cancels) < attempts {
+				launch()
+				pending++
+				timer.Reset(t.hedger.Delay())
+			}
+		}
+	}
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+	t.context.Set(AttemptsKey, len(cancels))
+	return nil, lastErr
+}
+
+// discard closes the response bodies of the given number of pending attempts.
+func discard(results chan result, pending int) {
+	for ; pending > 0; pending-- {
+		if r := <-results; r.res != nil {
+			r.res.Body.Close()
+		}
+	}
+}
+
+// cancelBody cancels the attempt context once the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements the io.Closer interface.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,121 @@
+package hedge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestHedgeSlowAttempt(t *testing.T) {
+	var calls, canceled int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+				atomic.AddInt32(&canceled, 1)
+				return
+			case <-time.After(time.Second):
+			}
+		}
+		fmt.Fprint(w, "hello")
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(New(Options{Delay: 20 * time.Millisecond}))
+
+	start := time.Now()
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "hello")
+	utils.Equal(t, time.Since(start) < 500*time.Millisecond, true)
+	utils.Equal(t, res.Context.Get(WinnerKey), 1)
+	utils.Equal(t, res.Context.Get(AttemptsKey), 2)
+
+	// Wait for the loser to be canceled
+	for i := 0; i < 100 && atomic.LoadInt32(&canceled) == 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	utils.Equal(t, atomic.LoadInt32(&canceled), int32(1))
+}
+
+func TestHedgeFastAttempt(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, "hello")
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(New(Options{Delay: time.Second}))
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "hello")
+	utils.Equal(t, res.Context.Get(WinnerKey), 0)
+	utils.Equal(t, atomic.LoadInt32(&calls), int32(1))
+}
+
+func TestHedgeNonIdempotent(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(New(Options{Delay: time.Millisecond}))
+	res, err := cli.Request().Method("POST").BodyString("foo").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.Context.Get(WinnerKey), nil)
+	utils.Equal(t, atomic.LoadInt32(&calls), int32(1))
+}
+
+func TestHedgeMaxHedges(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Duration(4-n) * 30 * time.Millisecond):
+			fmt.Fprintf(w, "attempt %d", n)
+		}
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(New(Options{Delay: 5 * time.Millisecond, MaxHedges: 2}))
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "attempt 3")
+	utils.Equal(t, res.Context.Get(AttemptsKey), 3)
+	utils.Equal(t, res.Context.Get(WinnerKey), 2)
+}
+
+func TestHedgeNetworkError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(New(Options{Delay: time.Second}))
+	_, err := cli.Request().Send()
+	utils.NotEqual(t, err, nil)
+}
+
+func TestHedgerPercentileDelay(t *testing.T) {
+	hg := New(Options{Delay: time.Second, Percentile: 0.9})
+	utils.Equal(t, hg.Delay(), time.Second)
+
+	for i := 1; i <= 10; i++ {
+		hg.observe(time.Duration(i) * time.Millisecond)
+	}
+	utils.Equal(t, hg.Delay(), 9*time.Millisecond)
+
+	for i := 0; i < maxSamples; i++ {
+		hg.observe(time.Millisecond)
+	}
+	utils.Equal(t, len(hg.samples), maxSamples)
+	utils.Equal(t, hg.Delay(), time.Millisecond)
+}
@@ -0,0 +1,190 @@
+package httpsig
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// member represents a structured field dictionary member.
+type member struct {
+	label string
+	value string
+}
+
+// component represents a covered component identifier.
+type component struct {
+	name string
+	req  bool
+}
+
+// String returns the serialized component identifier.
+func (c component) String() string {
+	if c.req {
+		return strconv.Quote(c.name) + ";req"
+	}
+	return strconv.Quote(c.name)
+}
+
+// parseComponent parses a component identifier in the simplified
+// form used by the options, such as "@method" or "content-type;req".
+func parseComponent(s string) (component, error) {
+	parts := strings.Split(strings.TrimSpace(s), ";")
+	comp := component{name: strings.ToLower(parts[0])}
+	for _, param := range parts[1:] {
+		if param != "req" {
+			return comp, fmt.Errorf("httpsig: unsupported component parameter: %s", param)
+		}
+		comp.req = true
+	}
+	return comp, nil
+}
+
+// parseComponents parses the given component identifiers.
+func parseComponents(list []string) ([]component, error) {
+	components := make([]component, len(list))
+	for i, s := range list {
+		comp, err := parseComponent(s)
+		if err != nil {
+			return nil, err
+		}
+		components[i] = comp
+	}
+	return components, nil
+}
+
+// signatureParams represents the parsed signature parameters of a Signature-Input member.
+type signatureParams struct {
+	raw        string
+	components []component
+	created    int64
+	expires    int64
+	keyID      string
+	alg        string
+	nonce      string
+	tag        string
+}
+
+// dictionary returns the members of the given dictionary header field,
+// combining multiple field lines.
+func dictionary(header http.Header, name string) []member {
+	members := []member{}
+	for _, item := range split(strings.Join(header.Values(name), ","), ',') {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.Index(item, "=")
+		if i < 0 {
+			members = append(members, member{label: item})
+			continue
+		}
+		members = append(members, member{item[:i], item[i+1:]})
+	}
+	return members
+}
+
+// setMember sets a dictionary member in the given header field,
+// replacing any existent member with the same label.
+func setMember(header http.Header, name, label, value string) {
+	items := []string{}
+	for _, m := range dictionary(header, name) {
+		if m.label != label {
+			items = append(items, m.label+"="+m.value)
+		}
+	}
+	header.Set(name, strings.Join(append(items, label+"="+value), ", "))
+}
+
+// split splits the given string by the separator,
+// ignoring separators inside strings and inner lists.
+func split(s string, sep byte) []string {
+	parts := []string{}
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case quoted && ch == '\\':
+			i++
+		case ch == '"':
+			quoted = !quoted
+		case quoted:
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// byteSequence parses a structured field byte sequence value.
+func byteSequence(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+		return "", false
+	}
+	return value[1 : len(value)-1], true
+}
+
+// parseSignatureParams parses the inner list and parameters of a Signature-Input member.
+func parseSignatureParams(raw string) (*signatureParams, error) {
+	sp := &signatureParams{raw: strings.TrimSpace(raw)}
+	s := sp.raw
+	if !strings.HasPrefix(s, "(") {
+		return nil, fmt.Errorf("httpsig: invalid signature input: %s", raw)
+	}
+
+	end := strings.LastIndex(s, ")")
+	if end < 0 {
+		return nil, fmt.Errorf("httpsig: invalid signature input: %s", raw)
+	}
+	for _, item := range strings.Fields(s[1:end]) {
+		parts := split(item, ';')
+		name, err := strconv.Unquote(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("httpsig: invalid component identifier: %s", parts[0])
+		}
+		comp := component{name: name}
+		for _, param := range parts[1:] {
+			if param != "req" {
+				return nil, fmt.Errorf("httpsig: unsupported component parameter: %s", param)
+			}
+			comp.req = true
+		}
+		sp.components = append(sp.components, comp)
+	}
+
+	for _, param := range split(s[end+1:], ';')[1:] {
+		key, value := param, ""
+		if i := strings.Index(param, "="); i >= 0 {
+			key, value = param[:i], param[i+1:]
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		var err error
+		switch key {
+		case "created":
+			sp.created, err = strconv.ParseInt(value, 10, 64)
+		case "expires":
+			sp.expires, err = strconv.ParseInt(value, 10, 64)
+		case "keyid":
+			sp.keyID = value
+		case "alg":
+			sp.alg = value
+		case "nonce":
+			sp.nonce = value
+		case "tag":
+			sp.tag = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("httpsig: invalid signature parameter: %s", param)
+		}
+	}
+	return sp, nil
+}
@@ -0,0 +1,425 @@
+package httpsig
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// DefaultLabel defines the default signature label.
+const DefaultLabel = "sig1"
+
+// DefaultComponents defines the components covered by default.
+// The content-digest component is also covered when the request has a body.
+var DefaultComponents = []string{"@method", "@target-uri"}
+
+// ErrMissingSignature is returned when a response carries no matching signature.
+var ErrMissingSignature = errors.New("httpsig: missing signature")
+
+// KeyFunc resolves the verification key of the given key identifier.
+type KeyFunc func(keyID string) (Key, error)
+
+// Keys returns a KeyFunc resolving the keys from the given map.
+func Keys(keys map[string]Key) KeyFunc {
+	return func(keyID string) (Key, error) {
+		if key, ok := keys[keyID]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("httpsig: unknown key: %q", keyID)
+	}
+}
+
+// Signer signs HTTP requests as defined by RFC 9421.
+type Signer struct {
+	// Label defines the signature label. Defaults to DefaultLabel.
+	Label string
+
+	// KeyID defines the key identifier sent in the keyid parameter.
+	KeyID string
+
+	// Key defines the signing key.
+	Key Key
+
+	// Components defines the covered components, such as @method,
+	// @authority or lowercase header names. Defaults to DefaultComponents.
+	// The Content-Digest header is generated if covered and not present.
+	Components []string
+
+	// Expires defines the signature validity. Zero omits the expires parameter.
+	Expires time.Duration
+
+	// Nonce enables a random nonce parameter.
+	Nonce bool
+
+	// Tag defines the optional application-specific tag parameter.
+	Tag string
+
+	// Algorithm enables the alg parameter, omitted by default
+	// since the algorithm is usually implied by the key identifier.
+	Algorithm bool
+}
+
+// Verifier verifies HTTP response signatures as defined by RFC 9421.
+type Verifier struct {
+	// Label defines the signature label to verify.
+	// If empty, the first signature is verified.
+	Label string
+
+	// Keys resolves the verification key by the keyid parameter.
+	Keys KeyFunc
+
+	// Components defines the components the signature must cover.
+	// Request components are defined with the req parameter, such as "@method;req".
+	// Signatures covering no components are always rejected.
+	Components []string
+
+	// MaxAge defines the maximum signature age. Zero disables the check.
+	MaxAge time.Duration
+}
+
+// New creates a new plugin signing the outgoing requests with the given signer.
+// Requests are signed before dialing, once the final request has been built.
+func New(signer *Signer) p.Plugin {
+	return p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		if err := signer.Sign(ctx.Request, time.Now()); err != nil {
+			h.Error(ctx, err)
+			return
+		}
+		h.Next(ctx)
+	})
+}
+
+// Verify creates a new plugin verifying the response signatures with the given verifier.
+func Verify(verifier *Verifier) p.Plugin {
+	return p.NewPhasePlugin("response", func(ctx *c.Context, h c.Handler) {
+		if ctx.Response.Request == nil {
+			ctx.Response.Request = ctx.Request
+		}
+		if err := verifier.Verify(ctx.Response, time.Now()); err != nil {
+			h.Error(ctx, err)
+			return
+		}
+		h.Next(ctx)
+	})
+}
+
+// Sign signs the given request at the given time,
+// adding the Signature and Signature-Input headers.
+func (s *Signer) Sign(req *http.Request, t time.Time) error {
+	list := s.Components
+	if list == nil {
+		list = DefaultComponents
+		if req.Body != nil && req.Body != http.NoBody {
+			list = append(list[:len(list):len(list)], "content-digest")
+		}
+	}
+	components, err := parseComponents(list)
+	if err != nil {
+		return err
+	}
+
+	for _, comp := range components {
+		if comp.name == "content-digest" && req.Header.Get("Content-Digest") == "" {
+			if err := setContentDigest(req); err != nil {
+				return err
+			}
+		}
+	}
+
+	params, err := s.params(components, t)
+	if err != nil {
+		return err
+	}
+	base, err := signatureBase(components, params, req, nil)
+	if err != nil {
+		return err
+	}
+	signature, err := s.Key.Sign(base)
+	if err != nil {
+		return err
+	}
+
+	label := s.Label
+	if label == "" {
+		label = DefaultLabel
+	}
+	setMember(req.Header, "Signature-Input", label, params)
+	setMember(req.Header, "Signature", label, ":"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+// params returns the serialized signature parameters.
+func (s *Signer) params(components []component, t time.Time) (string, error) {
+	items := make([]string, len(components))
+	for i, comp := range components {
+		items[i] = comp.String()
+	}
+
+	buf := &strings.Builder{}
+	buf.WriteString("(" + strings.Join(items, " ") + ")")
+	buf.WriteString(";created=" + strconv.FormatInt(t.Unix(), 10))
+	if s.Expires > 0 {
+		buf.WriteString(";expires=" + strconv.FormatInt(t.Add(s.Expires).Unix(), 10))
+	}
+	if s.Nonce {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		buf.WriteString(";nonce=" + strconv.Quote(base64.RawURLEncoding.EncodeToString(nonce)))
+	}
+	if s.KeyID != "" {
+		buf.WriteString(";keyid=" + strconv.Quote(s.KeyID))
+	}
+	if s.Algorithm {
+		buf.WriteString(";alg=" + strconv.Quote(s.Key.Algorithm()))
+	}
+	if s.Tag != "" {
+		buf.WriteString(";tag=" + strconv.Quote(s.Tag))
+	}
+	return buf.String(), nil
+}
+
+// Verify verifies the signature of the given response at the given time.
+// Request components are derived from the response request.
+func (v *Verifier) Verify(res *http.Response, t time.Time) error {
+	sp, signature, err := v.signature(res)
+	if err != nil {
+		return err
+	}
+
+	if len(sp.components) == 0 {
+		return fmt.Errorf("%w: no components covered", ErrInvalidSignature)
+	}
+	required, err := parseComponents(v.Components)
+	if err != nil {
+		return err
+	}
+	for _, comp := range required {
+		if !covers(sp.components, comp) {
+			return fmt.Errorf("%w: component %s not covered", ErrInvalidSignature, comp)
+		}
+	}
+
+	if sp.expires > 0 && t.Unix() > sp.expires {
+		return fmt.Errorf("%w: signature expired", ErrInvalidSignature)
+	}
+	if v.MaxAge > 0 && (sp.created == 0 || t.Sub(time.Unix(sp.created, 0)) > v.MaxAge) {
+		return fmt.Errorf("%w: signature too old", ErrInvalidSignature)
+	}
+
+	key, err := v.Keys(sp.keyID)
+	if err != nil {
+		return err
+	}
+	if sp.alg != "" && sp.alg != key.Algorithm() {
+		return fmt.Errorf("%w: unexpected algorithm %s", ErrInvalidSignature, sp.alg)
+	}
+
+	if covers(sp.components, component{name: "content-digest"}) {
+		if err := verifyContentDigest(res); err != nil {
+			return err
+		}
+	}
+
+	base, err := signatureBase(sp.components, sp.raw, res.Request, res)
+	if err != nil {
+		return err
+	}
+	return key.Verify(base, signature)
+}
+
+// signature returns the signature parameters and the signature to verify.
+func (v *Verifier) signature(res *http.Response) (*signatureParams, []byte, error) {
+	var input *member
+	for _, m := range dictionary(res.Header, "Signature-Input") {
+		if v.Label == "" || m.label == v.Label {
+			input = &m
+			break
+		}
+	}
+	if input == nil {
+		return nil, nil, ErrMissingSignature
+	}
+
+	for _, m := range dictionary(res.Header, "Signature") {
+		if m.label != input.label {
+			continue
+		}
+		encoded, ok := byteSequence(m.value)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: invalid encoding", ErrInvalidSignature)
+		}
+		signature, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid encoding", ErrInvalidSignature)
+		}
+		sp, err := parseSignatureParams(input.value)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sp, signature, nil
+	}
+	return nil, nil, ErrMissingSignature
+}
+
+// covers reports whether the given component is covered.
+func covers(components []component, comp component) bool {
+	for _, covered := range components {
+		if covered == comp {
+			return true
+		}
+	}
+	return false
+}
+
+// signatureBase builds the signature base of the given components and signature parameters.
+// Components are derived from the response, if present, unless flagged with the req parameter.
+func signatureBase(components []component, params string, req *http.Request, res *http.Response) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for _, comp := range components {
+		value, err := componentValue(comp, req, res)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(comp.String() + ": " + value + "\n")
+	}
+	buf.WriteString(`"@signature-params": ` + params)
+	return buf.Bytes(), nil
+}
+
+// componentValue returns the value of the given component.
+func componentValue(comp component, req *http.Request, res *http.Response) (string, error) {
+	if res != nil && !comp.req {
+		if comp.name == "@status" {
+			return strconv.Itoa(res.StatusCode), nil
+		}
+		return headerValue(comp, res.Header)
+	}
+	if req == nil {
+		return "", fmt.Errorf("httpsig: missing request for component %s", comp)
+	}
+
+	switch comp.name {
+	case "@method":
+		return req.Method, nil
+	case "@target-uri":
+		return strings.ToLower(req.URL.Scheme) + "://" + authority(req) + req.URL.RequestURI(), nil
+	case "@authority":
+		return authority(req), nil
+	case "@scheme":
+		return strings.ToLower(req.URL.Scheme), nil
+	case "@request-target":
+		return req.URL.RequestURI(), nil
+	case "@path":
+		if path := req.URL.EscapedPath(); path != "" {
+			return path, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + req.URL.RawQuery, nil
+	}
+
+	if comp.name == "content-length" && req.Header.Get("Content-Length") == "" && req.ContentLength > 0 {
+		return strconv.FormatInt(req.ContentLength, 10), nil
+	}
+	return headerValue(comp, req.Header)
+}
+
+// headerValue returns the canonical value of the given header component.
+func headerValue(comp component, header http.Header) (string, error) {
+	if strings.HasPrefix(comp.name, "@") {
+		return "", fmt.Errorf("httpsig: unsupported component %s", comp)
+	}
+	values := header.Values(comp.name)
+	if len(values) == 0 {
+		return "", fmt.Errorf("httpsig: missing component %s", comp)
+	}
+	// Trim a copy to preserve the original header values
+	trimmed := make([]string, len(values))
+	for i, value := range values {
+		trimmed[i] = strings.TrimSpace(value)
+	}
+	return strings.Join(trimmed, ", "), nil
+}
+
+// authority returns the lowercase request authority without the default port.
+func authority(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	host = strings.ToLower(host)
+	if strings.HasSuffix(host, ":80") && req.URL.Scheme == "http" {
+		return strings.TrimSuffix(host, ":80")
+	}
+	if strings.HasSuffix(host, ":443") && req.URL.Scheme == "https" {
+		return strings.TrimSuffix(host, ":443")
+	}
+	return host
+}
+
+// setContentDigest sets the SHA-256 Content-Digest header of the request body.
+func setContentDigest(req *http.Request) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		buf, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+		req.ContentLength = int64(len(buf))
+		body = buf
+	}
+	sum := sha256.Sum256(body)
+	req.Header.Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+	return nil
+}
+
+// verifyContentDigest verifies the Content-Digest header of the response body.
+// At least one supported digest algorithm is required.
+func verifyContentDigest(res *http.Response) error {
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	verified := false
+	for _, m := range dictionary(res.Header, "Content-Digest") {
+		var sum []byte
+		switch m.label {
+		case "sha-256":
+			digest := sha256.Sum256(body)
+			sum = digest[:]
+		case "sha-512":
+			digest := sha512.Sum512(body)
+			sum = digest[:]
+		default:
+			continue
+		}
+		encoded, _ := byteSequence(m.value)
+		if encoded != base64.StdEncoding.EncodeToString(sum) {
+			return fmt.Errorf("%w: content digest mismatch", ErrInvalidSignature)
+		}
+		verified = true
+	}
+	if !verified {
+		return fmt.Errorf("%w: missing content digest", ErrInvalidSignature)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+)
+
+// Algorithm names as defined by the HTTP Signature Algorithms registry.
+const (
+	AlgHMACSHA256      = "hmac-sha256"
+	AlgEd25519         = "ed25519"
+	AlgECDSAP256SHA256 = "ecdsa-p256-sha256"
+	AlgECDSAP384SHA384 = "ecdsa-p384-sha384"
+	AlgRSAPSSSHA512    = "rsa-pss-sha512"
+)
+
+// ErrInvalidSignature is returned when a signature does not match.
+var ErrInvalidSignature = errors.New("httpsig: invalid signature")
+
+// errPublicKey is returned when signing with a verification-only key.
+var errPublicKey = errors.New("httpsig: cannot sign with a public key")
+
+// Key represents the interface implemented by signing and verification keys.
+type Key interface {
+	// Algorithm returns the algorithm name.
+	Algorithm() string
+
+	// Sign signs the given signature base.
+	Sign(base []byte) ([]byte, error)
+
+	// Verify verifies the signature of the given signature base.
+	Verify(base, signature []byte) error
+}
+
+// hmacKey implements a HMAC-SHA256 shared secret key.
+type hmacKey []byte
+
+// HMACSHA256 creates a new HMAC-SHA256 key based on the given shared secret.
+func HMACSHA256(secret []byte) Key {
+	return hmacKey(secret)
+}
+
+// Algorithm implements the Key interface.
+func (k hmacKey) Algorithm() string {
+	return AlgHMACSHA256
+}
+
+// Sign implements the Key interface.
+func (k hmacKey) Sign(base []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, k)
+	mac.Write(base)
+	return mac.Sum(nil), nil
+}
+
+// Verify implements the Key interface.
+func (k hmacKey) Verify(base, signature []byte) error {
+	expected, _ := k.Sign(base)
+	if !hmac.Equal(expected, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ed25519Key implements an Ed25519 key.
+type ed25519Key struct {
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// Ed25519 creates a new Ed25519 signing key.
+func Ed25519(key ed25519.PrivateKey) Key {
+	return &ed25519Key{key, key.Public().(ed25519.PublicKey)}
+}
+
+// Ed25519Public creates a new Ed25519 verification key.
+func Ed25519Public(key ed25519.PublicKey) Key {
+	return &ed25519Key{public: key}
+}
+
+// Algorithm implements the Key interface.
+func (k *ed25519Key) Algorithm() string {
+	return AlgEd25519
+}
+
+// Sign implements the Key interface.
+func (k *ed25519Key) Sign(base []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, errPublicKey
+	}
+	return ed25519.Sign(k.private, base), nil
+}
+
+// Verify implements the Key interface.
+func (k *ed25519Key) Verify(base, signature []byte) error {
+	if !ed25519.Verify(k.public, base, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ecdsaKey implements an ECDSA key using the P-256 or P-384 curves.
+type ecdsaKey struct {
+	private *ecdsa.PrivateKey
+	public  *ecdsa.PublicKey
+}
+
+// ECDSA creates a new ECDSA signing key. The algorithm is
+// ecdsa-p384-sha384 for P-384 keys, otherwise ecdsa-p256-sha256.
+func ECDSA(key *ecdsa.PrivateKey) Key {
+	return &ecdsaKey{key, &key.PublicKey}
+}
+
+// ECDSAPublic creates a new ECDSA verification key.
+func ECDSAPublic(key *ecdsa.PublicKey) Key {
+	return &ecdsaKey{public: key}
+}
+
+// Algorithm implements the Key interface.
+func (k *ecdsaKey) Algorithm() string {
+	if k.public.Curve == elliptic.P384() {
+		return AlgECDSAP384SHA384
+	}
+	return AlgECDSAP256SHA256
+}
+
+// digest returns the message digest and the size of the signature integers.
+func (k *ecdsaKey) digest(base []byte) ([]byte, int) {
+	if k.public.Curve == elliptic.P384() {
+		sum := sha512.Sum384(base)
+		return sum[:], 48
+	}
+	sum := sha256.Sum256(base)
+	return sum[:], 32
+}
+
+// Sign implements the Key interface.
+// The signature is the concatenation of the fixed-size r and s integers.
+func (k *ecdsaKey) Sign(base []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, errPublicKey
+	}
+
+	digest, size := k.digest(base)
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return signature, nil
+}
+
+// Verify implements the Key interface.
+func (k *ecdsaKey) Verify(base, signature []byte) error {
+	digest, size := k.digest(base)
+	if len(signature) != 2*size {
+		return ErrInvalidSignature
+	}
+
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(k.public, digest, r, s) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// rsaPSSKey implements a RSASSA-PSS key using SHA-512.
+type rsaPSSKey struct {
+	private *rsa.PrivateKey
+	public  *rsa.PublicKey
+}
+
+// pssOptions defines the RSASSA-PSS parameters.
+var pssOptions = &rsa.PSSOptions{SaltLength: 64, Hash: crypto.SHA512}
+
+// RSAPSS creates a new RSASSA-PSS signing key.
+func RSAPSS(key *rsa.PrivateKey) Key {
+	return &rsaPSSKey{key, &key.PublicKey}
+}
+
+// RSAPSSPublic creates a new RSASSA-PSS verification key.
+func RSAPSSPublic(key *rsa.PublicKey) Key {
+	return &rsaPSSKey{public: key}
+}
+
+// Algorithm implements the Key interface.
+func (k *rsaPSSKey) Algorithm() string {
+	return AlgRSAPSSSHA512
+}
+
+// Sign implements the Key interface.
+func (k *rsaPSSKey) Sign(base []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, errPublicKey
+	}
+	digest := sha512.Sum512(base)
+	return rsa.SignPSS(rand.Reader, k.private, crypto.SHA512, digest[:], pssOptions)
+}
+
+// Verify implements the Key interface.
+func (k *rsaPSSKey) Verify(base, signature []byte) error {
+	digest := sha512.Sum512(base)
+	if err := rsa.VerifyPSS(k.public, crypto.SHA512, digest[:], signature, pssOptions); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package limit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// TempDir defines the directory used to store spilled response bodies.
+// Defaults to the operating system temporary directory.
+var TempDir = ""
+
+// BodyTooLargeError is reported when a response body exceeds the maximum allowed size.
+type BodyTooLargeError struct {
+	// Limit stores the maximum allowed size in bytes.
+	Limit int64
+
+	// Size stores the response size announced via Content-Length,
+	// or -1 if the limit was exceeded while reading the body.
+	Size int64
+}
+
+// Error implements the error interface.
+func (e *BodyTooLargeError) Error() string {
+	if e.Size < 0 {
+		return fmt.Sprintf("limit: response body exceeds %d bytes", e.Limit)
+	}
+	return fmt.Sprintf("limit: response body of %d bytes exceeds %d bytes", e.Size, e.Limit)
+}
+
+// Body defines the maximum size in bytes of the response body.
+// Responses announcing a bigger Content-Length fail before reading the body,
+// otherwise the limit is enforced while reading, failing with *BodyTooLargeError.
+func Body(max int64) p.Plugin {
+	return p.NewPhasePlugin("after dial", func(ctx *c.Context, h c.Handler) {
+		res := ctx.Response
+		if res.ContentLength > max {
+			res.Body.Close()
+			h.Error(ctx, &BodyTooLargeError{Limit: max, Size: res.ContentLength})
+			return
+		}
+
+		res.Body = &limitedReader{body: res.Body, limit: max, remaining: max}
+		h.Next(ctx)
+	})
+}
+
+// Spill reads the whole response body, keeping in memory up to the given amount of bytes.
+// Bigger bodies are spilled into a temporary file that is removed once the body
+// is read until EOF or closed. The response body can be then consumed as usual
+// without holding the connection.
+// Bodies bigger than max bytes fail with *BodyTooLargeError, like Body.
+func Spill(memory, max int64) p.Plugin {
+	return p.NewPhasePlugin("after dial", func(ctx *c.Context, h c.Handler) {
+		res := ctx.Response
+		if res.ContentLength > max {
+			res.Body.Close()
+			h.Error(ctx, &BodyTooLargeError{Limit: max, Size: res.ContentLength})
+			return
+		}
+
+		limited := &limitedReader{body: res.Body, limit: max, remaining: max}
+		body, size, err := spill(limited, memory)
+		if err != nil {
+			h.Error(ctx, err)
+			return
+		}
+
+		ctx.Response.Body = body
+		ctx.Response.ContentLength = size
+		h.Next(ctx)
+	})
+}
+
+// limitedReader reads from the body failing once the limit is exceeded.
+type limitedReader struct {
+	body      io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+// Read implements the io.Reader interface.
+func (l *limitedReader) Read(buf []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, &BodyTooLargeError{Limit: l.limit, Size: -1}
+	}
+
+	// Read one extra byte to detect the overflow
+	if int64(len(buf)) > l.remaining+1 {
+		buf = buf[:l.remaining+1]
+	}
+
+	n, err := l.body.Read(buf)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), &BodyTooLargeError{Limit: l.limit, Size: -1}
+	}
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (l *limitedReader) Close() error {
+	return l.body.Close()
+}
+
+// spill reads the given body into memory or into a temporary file
+// if it is bigger than the given amount of bytes.
+func spill(body io.ReadCloser, memory int64) (io.ReadCloser, int64, error) {
+	defer body.Close()
+
+	buf := &bytes.Buffer{}
+	n, err := io.CopyN(buf, body, memory+1)
+	if err == io.EOF {
+		return ioutil.NopCloser(buf), n, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	file, err := ioutil.TempFile(TempDir, "gentleman-")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tmp := &tempFile{File: file}
+	// Remove the file even if the body is never consumed nor closed
+	runtime.SetFinalizer(tmp, (*tempFile).Close)
+
+	size, err := io.Copy(file, io.MultiReader(buf, body))
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		return nil, 0, err
+	}
+
+	return tmp, size, nil
+}
+
+// tempFile is a temporary file removed once read until EOF or closed.
+type tempFile struct {
+	*os.File
+	once sync.Once
+	err  error
+}
+
+// Read implements the io.Reader interface.
+func (f *tempFile) Read(buf []byte) (int, error) {
+	n, err := f.File.Read(buf)
+	if err == io.EOF {
+		f.Close()
+	}
+	return n, err
+}
+
+// Close closes and removes the temporary file.
+// Subsequent calls return the result of the first one.
+func (f *tempFile) Close() error {
+	f.once.Do(func() {
+		runtime.SetFinalizer(f, nil)
+		f.err = f.File.Close()
+		os.Remove(f.Name())
+	})
+	return f.err
+}
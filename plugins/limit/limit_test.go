@@ -0,0 +1,141 @@
+package limit
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestBodyContentLength(t *testing.T) {
+	ctx := context.New()
+	utils.WriteBodyString(ctx.Response, "foo bar")
+
+	fn := newHandler()
+	Body(5).Exec("after dial", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+	utils.NotEqual(t, ctx.Error, nil)
+	utils.Equal(t, ctx.Error.Error(), "limit: response body of 7 bytes exceeds 5 bytes")
+}
+
+func TestBodyStreaming(t *testing.T) {
+	ctx := context.New()
+	utils.WriteBodyString(ctx.Response, "foo bar")
+	ctx.Response.ContentLength = -1
+
+	fn := newHandler()
+	Body(5).Exec("after dial", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+	utils.Equal(t, ctx.Error, nil)
+
+	buf, err := ioutil.ReadAll(ctx.Response.Body)
+	var tooLarge *BodyTooLargeError
+	utils.Equal(t, errors.As(err, &tooLarge), true)
+	utils.Equal(t, tooLarge.Limit, int64(5))
+	utils.Equal(t, string(buf), "foo b")
+}
+
+func TestBodyWithinLimit(t *testing.T) {
+	ctx := context.New()
+	utils.WriteBodyString(ctx.Response, "foo bar")
+	ctx.Response.ContentLength = -1
+
+	fn := newHandler()
+	Body(7).Exec("after dial", ctx, fn.fn)
+	utils.Equal(t, ctx.Error, nil)
+
+	buf, err := ioutil.ReadAll(ctx.Response.Body)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, string(buf), "foo bar")
+}
+
+func TestSpillMemory(t *testing.T) {
+	ctx := context.New()
+	utils.WriteBodyString(ctx.Response, "foo bar")
+
+	fn := newHandler()
+	Spill(10, 1000).Exec("after dial", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+	utils.Equal(t, ctx.Error, nil)
+
+	_, ok := ctx.Response.Body.(*tempFile)
+	utils.Equal(t, ok, false)
+	buf, _ := ioutil.ReadAll(ctx.Response.Body)
+	utils.Equal(t, string(buf), "foo bar")
+}
+
+func TestSpillFile(t *testing.T) {
+	body := strings.Repeat("foo bar ", 100)
+	ctx := context.New()
+	utils.WriteBodyString(ctx.Response, body)
+	ctx.Response.ContentLength = -1
+
+	fn := newHandler()
+	Spill(10, 1000).Exec("after dial", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+	utils.Equal(t, ctx.Error, nil)
+	utils.Equal(t, ctx.Response.ContentLength, int64(len(body)))
+
+	file, ok := ctx.Response.Body.(*tempFile)
+	utils.Equal(t, ok, true)
+	buf, _ := ioutil.ReadAll(ctx.Response.Body)
+	utils.Equal(t, string(buf), body)
+
+	// The file is removed once read until EOF
+	_, err := os.Stat(file.Name())
+	utils.Equal(t, os.IsNotExist(err), true)
+	utils.Equal(t, ctx.Response.Body.Close(), nil)
+}
+
+func TestSpillFileClose(t *testing.T) {
+	ctx := context.New()
+	utils.WriteBodyString(ctx.Response, strings.Repeat("foo bar ", 100))
+
+	fn := newHandler()
+	Spill(10, 1000).Exec("after dial", ctx, fn.fn)
+	utils.Equal(t, ctx.Error, nil)
+
+	file := ctx.Response.Body.(*tempFile)
+	utils.Equal(t, ctx.Response.Body.Close(), nil)
+	_, err := os.Stat(file.Name())
+	utils.Equal(t, os.IsNotExist(err), true)
+}
+
+func TestSpillTooLarge(t *testing.T) {
+	ctx := context.New()
+	utils.WriteBodyString(ctx.Response, strings.Repeat("foo bar ", 100))
+
+	fn := newHandler()
+	Spill(10, 100).Exec("after dial", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+	utils.NotEqual(t, ctx.Error, nil)
+	utils.Equal(t, ctx.Error.Error(), "limit: response body of 800 bytes exceeds 100 bytes")
+
+	ctx = context.New()
+	utils.WriteBodyString(ctx.Response, strings.Repeat("foo bar ", 100))
+	ctx.Response.ContentLength = -1
+
+	fn = newHandler()
+	Spill(10, 100).Exec("after dial", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+	var tooLarge *BodyTooLargeError
+	utils.Equal(t, errors.As(ctx.Error, &tooLarge), true)
+	utils.Equal(t, tooLarge.Size, int64(-1))
+}
+
+type handler struct {
+	fn     context.Handler
+	called bool
+}
+
+func newHandler() *handler {
+	h := &handler{}
+	h.fn = context.NewHandler(func(c *context.Context) {
+		h.called = true
+	})
+	return h
+}
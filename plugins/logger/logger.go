@@ -0,0 +1,293 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+const (
+	// LoggerKey stores the context store key of the request-scoped logger.
+	LoggerKey = "$logger"
+
+	// startKey stores the context store key of the request dial time.
+	startKey = "$logger.start"
+
+	// doneKey stores the context store key reporting that the outcome was logged.
+	doneKey = "$logger.done"
+
+	// Redacted defines the value replacing the redacted values.
+	Redacted = "[REDACTED]"
+
+	// DefaultMaxBodySize defines the default maximum number of body bytes logged.
+	DefaultMaxBodySize = 1024
+)
+
+// DefaultRedactHeaders stores the headers redacted by default.
+var DefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Options represents the logger plugin options.
+type Options struct {
+	// Logger defines the logger to use. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	// RequestLevel defines the level of the request lines. Defaults to slog.LevelDebug.
+	RequestLevel slog.Leveler
+
+	// ResponseLevel defines the level of the response lines. Defaults to slog.LevelDebug.
+	ResponseLevel slog.Leveler
+
+	// ServerErrorLevel defines the level of the 5xx response lines. Defaults to slog.LevelWarn.
+	ServerErrorLevel slog.Leveler
+
+	// ErrorLevel defines the level of the failed request lines. Defaults to slog.LevelError.
+	ErrorLevel slog.Leveler
+
+	// Headers enables logging the request and response headers.
+	Headers bool
+
+	// Body enables logging the request and response bodies, truncated to MaxBodySize.
+	Body bool
+
+	// MaxBodySize defines the maximum number of body bytes logged.
+	// Defaults to DefaultMaxBodySize.
+	MaxBodySize int
+
+	// RedactHeaders defines the headers whose values are redacted.
+	// Defaults to DefaultRedactHeaders.
+	RedactHeaders []string
+
+	// RedactQuery defines the query params, and URL-encoded form
+	// body fields, whose values are redacted.
+	RedactQuery []string
+
+	// RedactFields defines the JSON body fields, at any depth,
+	// whose values are redacted. Field names are case insensitive.
+	RedactFields []string
+}
+
+// GetLogger returns the request-scoped logger stored in the given Context,
+// or slog.Default() if the logger plugin is not used.
+// Since the before dial phase, the logger includes the request method and URL attributes.
+func GetLogger(ctx *c.Context) *slog.Logger {
+	if logger, ok := ctx.Get(LoggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// New creates a new plugin logging the request and response lines.
+func New(opts Options) p.Plugin {
+	if opts.RequestLevel == nil {
+		opts.RequestLevel = slog.LevelDebug
+	}
+	if opts.ResponseLevel == nil {
+		opts.ResponseLevel = slog.LevelDebug
+	}
+	if opts.ServerErrorLevel == nil {
+		opts.ServerErrorLevel = slog.LevelWarn
+	}
+	if opts.ErrorLevel == nil {
+		opts.ErrorLevel = slog.LevelError
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.RedactHeaders == nil {
+		opts.RedactHeaders = DefaultRedactHeaders
+	}
+	l := &logger{opts}
+
+	plu := p.New()
+	plu.SetHandlers(p.Handlers{
+		"request": func(ctx *c.Context, h c.Handler) {
+			ctx.Set(LoggerKey, l.base())
+			h.Next(ctx)
+		},
+		"before dial": func(ctx *c.Context, h c.Handler) {
+			l.request(ctx)
+			h.Next(ctx)
+		},
+		"response": func(ctx *c.Context, h c.Handler) {
+			l.response(ctx)
+			h.Next(ctx)
+		},
+		"error": func(ctx *c.Context, h c.Handler) {
+			l.error(ctx)
+			h.Next(ctx)
+		},
+	})
+	return plu
+}
+
+// logger implements the logging handlers.
+type logger struct {
+	opts Options
+}
+
+// base returns the configured logger.
+func (l *logger) base() *slog.Logger {
+	if l.opts.Logger != nil {
+		return l.opts.Logger
+	}
+	return slog.Default()
+}
+
+// bind stores the request-scoped logger including the request attributes.
+func (l *logger) bind(ctx *c.Context) *slog.Logger {
+	logger := l.base().With(slog.String("method", ctx.Request.Method), slog.String("url", l.url(ctx.Request)))
+	ctx.Set(LoggerKey, logger)
+	return logger
+}
+
+// request logs the request line once the request is ready to be dialed.
+func (l *logger) request(ctx *c.Context) {
+	ctx.Set(startKey, time.Now())
+	logger := l.bind(ctx)
+
+	level := l.opts.RequestLevel.Level()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{}
+	if l.opts.Headers {
+		attrs = append(attrs, l.headers(ctx.Request.Header))
+	}
+	if l.opts.Body && ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+		var body string
+		body, ctx.Request.Body = l.body(ctx.Request.Body, ctx.Request.Header.Get("Content-Type"))
+		attrs = append(attrs, slog.String("body", body))
+	}
+	logger.LogAttrs(ctx, level, "http request", attrs...)
+}
+
+// response logs the response line.
+func (l *logger) response(ctx *c.Context) {
+	logger, start := l.done(ctx)
+	if logger == nil {
+		return
+	}
+
+	level := l.opts.ResponseLevel.Level()
+	if ctx.Response.StatusCode >= 500 {
+		level = l.opts.ServerErrorLevel.Level()
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{slog.Int("status", ctx.Response.StatusCode)}
+	if !start.IsZero() {
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+	}
+	if l.opts.Headers {
+		attrs = append(attrs, l.headers(ctx.Response.Header))
+	}
+	if l.opts.Body && ctx.Response.Body != nil && ctx.Response.Body != http.NoBody && !writable(ctx.Response.Body) {
+		var body string
+		body, ctx.Response.Body = l.body(ctx.Response.Body, ctx.Response.Header.Get("Content-Type"))
+		attrs = append(attrs, slog.String("body", body))
+	}
+	logger.LogAttrs(ctx, level, "http response", attrs...)
+}
+
+// error logs the failed request line.
+func (l *logger) error(ctx *c.Context) {
+	logger, start := l.done(ctx)
+	if logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{slog.String("error", ctx.Error.Error())}
+	if !start.IsZero() {
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+	}
+	logger.LogAttrs(ctx, l.opts.ErrorLevel.Level(), "http request failed", attrs...)
+}
+
+// done returns the request-scoped logger and dial time, once per request.
+// Intercepted requests are logged without duration.
+func (l *logger) done(ctx *c.Context) (*slog.Logger, time.Time) {
+	if _, ok := ctx.GetOk(doneKey); ok {
+		return nil, time.Time{}
+	}
+	ctx.Set(doneKey, true)
+
+	start, ok := ctx.Get(startKey).(time.Time)
+	if !ok {
+		return l.bind(ctx), time.Time{}
+	}
+	return GetLogger(ctx), start
+}
+
+// url returns the request URL with the password and configured query params redacted.
+func (l *logger) url(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = redactQuery(u.RawQuery, l.opts.RedactQuery)
+	return u.Redacted()
+}
+
+// headers returns the headers group attribute with the configured headers redacted.
+func (l *logger) headers(header http.Header) slog.Attr {
+	redact := map[string]bool{}
+	for _, name := range l.opts.RedactHeaders {
+		redact[http.CanonicalHeaderKey(name)] = true
+	}
+
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make([]any, len(names))
+	for i, name := range names {
+		value := strings.Join(header[name], ", ")
+		if redact[http.CanonicalHeaderKey(name)] {
+			value = Redacted
+		}
+		attrs[i] = slog.String(name, value)
+	}
+	return slog.Group("headers", attrs...)
+}
+
+// body peeks the body up to MaxBodySize bytes, returning the redacted body string
+// and a body reader replaying the peeked bytes.
+func (l *logger) body(body io.ReadCloser, contentType string) (string, io.ReadCloser) {
+	buf := make([]byte, l.opts.MaxBodySize+1)
+	n, err := io.ReadFull(body, buf)
+	data := buf[:n]
+
+	replay := io.MultiReader(bytes.NewReader(data), body)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		replay = bytes.NewReader(data)
+	}
+	body = readCloser{replay, body}
+
+	truncated := len(data) > l.opts.MaxBodySize
+	if truncated {
+		data = data[:l.opts.MaxBodySize]
+	}
+	return redactBody(data, contentType, truncated, l.opts), body
+}
+
+// writable reports whether the body is writable, as in upgraded connections,
+// in which case it must not be wrapped.
+func writable(body io.ReadCloser) bool {
+	_, ok := body.(io.Writer)
+	return ok
+}
+
+// readCloser combines a reader with the original body closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
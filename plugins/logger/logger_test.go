@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lytics/gentleman"
+	c "github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/utils"
+)
+
+func newLogger(buf *bytes.Buffer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && (attr.Key == slog.TimeKey || attr.Key == "duration") {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+}
+
+func TestLogger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(503)
+		}
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{
+		Logger:       newLogger(&buf, slog.LevelDebug),
+		Headers:      true,
+		Body:         true,
+		RedactQuery:  []string{"token"},
+		RedactFields: []string{"password"},
+	}))
+
+	res, err := cli.Request().Method("POST").Path("/users").AddQuery("token", "abc").AddQuery("page", "2").
+		SetHeader("Authorization", "Bearer secret").
+		JSON(map[string]interface{}{"name": "alice", "auth": map[string]string{"Password": "secret"}}).Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), `{"auth":{"Password":"secret"},"name":"alice"}`+"\n")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	utils.Equal(t, len(lines), 2)
+	url := ts.URL + "/users?page=2&token=[REDACTED]"
+	utils.Equal(t, strings.HasPrefix(lines[0], `level=DEBUG msg="http request" method=POST url="`+url+`"`), true)
+	utils.Equal(t, strings.Contains(lines[0], `headers.Authorization=[REDACTED]`), true)
+	utils.Equal(t, strings.Contains(lines[0], `body="{\"auth\":{\"Password\":\"[REDACTED]\"},\"name\":\"alice\"}"`), true)
+	utils.Equal(t, strings.HasPrefix(lines[1], `level=DEBUG msg="http response" method=POST url="`+url+`" status=200`), true)
+	utils.Equal(t, strings.Contains(lines[1], `headers.Set-Cookie=[REDACTED]`), true)
+	utils.Equal(t, strings.Contains(lines[1], `headers.Content-Type=application/json`), true)
+
+	buf.Reset()
+	res, err = cli.Request().Path("/fail").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 503)
+	utils.Equal(t, strings.Contains(buf.String(), `level=WARN msg="http response" method=GET url=`+ts.URL+"/fail status=503"), true)
+}
+
+func TestLoggerLevels(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Logger: newLogger(&buf, slog.LevelInfo)}))
+	_, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, buf.String(), "")
+
+	cli = gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Logger: newLogger(&buf, slog.LevelInfo), RequestLevel: slog.LevelInfo}))
+	_, err = cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, buf.String(), `level=INFO msg="http request" method=GET url=`+ts.URL+"\n")
+}
+
+func TestLoggerError(t *testing.T) {
+	var buf bytes.Buffer
+	cli := gentleman.New().URL("http://example.com")
+	cli.Use(New(Options{Logger: newLogger(&buf, slog.LevelError)}))
+	cli.UseHandler("before dial", func(ctx *c.Context, h c.Handler) {
+		ctx.Client.Transport = failTransport{}
+		h.Next(ctx)
+	})
+
+	_, err := cli.Request().Send()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, strings.HasPrefix(buf.String(), `level=ERROR msg="http request failed" method=GET url=http://example.com error=`), true)
+	utils.Equal(t, strings.Count(buf.String(), "\n"), 1)
+}
+
+func TestLoggerContext(t *testing.T) {
+	var buf bytes.Buffer
+	cli := gentleman.New().URL("http://example.com/users")
+	cli.Use(New(Options{Logger: newLogger(&buf, slog.LevelInfo)}))
+	cli.UseHandler("before dial", func(ctx *c.Context, h c.Handler) {
+		GetLogger(ctx).Info("cache hit")
+		ctx.Response.StatusCode = 200
+		h.Next(ctx)
+	})
+
+	_, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, buf.String(), `level=INFO msg="cache hit" method=GET url=http://example.com/users`+"\n")
+	utils.Equal(t, GetLogger(c.New()), slog.Default())
+}
+
+func TestLoggerBodyTruncated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"password":"secret","token":12345,"data":"` + strings.Repeat("x", 100) + `"}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Logger: newLogger(&buf, slog.LevelDebug), Body: true, MaxBodySize: 50, RedactFields: []string{"password", "token"}}))
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, len(res.Bytes()), 145)
+	utils.Equal(t, strings.Contains(buf.String(), `body="{\"password\":\"[REDACTED]\",\"token\":\"[REDACTED]\",\"data\":\"xxxxxxx..."`), true)
+}
+
+func TestRedactBody(t *testing.T) {
+	opts := Options{RedactQuery: []string{"secret"}, RedactFields: []string{"key"}}
+	utils.Equal(t, redactBody([]byte("a=1&secret=2"), "application/x-www-form-urlencoded", false, opts), "a=1&secret=[REDACTED]")
+	utils.Equal(t, redactBody([]byte(`[{"key":[1,2]},{"n":1.50}]`), "application/vnd.api+json", false, opts), `[{"key":"[REDACTED]"},{"n":1.50}]`)
+	utils.Equal(t, redactBody([]byte(`{"key":"a<b"}`), "text/plain", false, opts), `{"key":"a<b"}`)
+	utils.Equal(t, redactBody([]byte{0xff, 0xfe}, "", false, opts), "[binary]")
+	utils.Equal(t, redactBody([]byte("h\xc3\xa9"[:2]), "", true, opts), "h...")
+}
+
+func TestRedactBodyTruncated(t *testing.T) {
+	opts := Options{RedactFields: []string{"key", "password"}}
+	cases := []struct {
+		body, want string
+	}{
+		{`{"a":1,"key":{"id":"secret"},"b":[{"password":"sec`, `{"a":1,"key":"[REDACTED]","b":[{"password":"[REDACTED]"...`},
+		{`{"key":["secret",{"x":`, `{"key":"[REDACTED]"...`},
+		{`{"key": "secret`, `{"key": "[REDACTED]"...`},
+		{`{"key"`, `{"key"...`},
+		{`{"key":`, `{"key":...`},
+		{`[{"Password":"secret"},{"n":"key","key":[1,2]},{"other":"val`, `[{"Password":"[REDACTED]"},{"n":"key","key":"[REDACTED]"},{"other":"val...`},
+		{`{"a":1}x{"key":"secret"`, `{"a":1}[REDACTED]...`},
+	}
+	for _, test := range cases {
+		utils.Equal(t, redactBody([]byte(test.body), "application/json", true, opts), test.want)
+	}
+}
+
+type failTransport struct{}
+
+func (failTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
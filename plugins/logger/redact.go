@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// redactQuery redacts the values of the given params in the given query string,
+// preserving the params order and encoding.
+func redactQuery(query string, params []string) string {
+	if query == "" || len(params) == 0 {
+		return query
+	}
+
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		key := strings.SplitN(pair, "=", 2)[0]
+		if name, err := url.QueryUnescape(key); err == nil && contains(params, name, false) {
+			pairs[i] = key + "=" + Redacted
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// redactBody returns the given body as string, redacting the configured
+// JSON and form fields. Truncated bodies are suffixed by an ellipsis.
+func redactBody(data []byte, contentType string, truncated bool, opts Options) string {
+	if truncated {
+		// Drop the incomplete trailing rune, if any
+		for i := 0; i < utf8.UTFMax-1 && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	if !utf8.Valid(data) {
+		return "[binary]"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	body := string(data)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		body = redactQuery(body, opts.RedactQuery)
+	case len(opts.RedactFields) > 0 && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")):
+		body = redactJSON(data, truncated, opts.RedactFields)
+	}
+
+	if truncated {
+		body += "..."
+	}
+	return body
+}
+
+// redactJSON redacts the values of the given fields at any depth.
+// Documents which cannot be decoded, such as truncated ones, are redacted
+// token by token instead.
+func redactJSON(data []byte, truncated bool, fields []string) string {
+	if !truncated {
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err == nil {
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(redactValue(value, fields)); err == nil {
+				return strings.TrimSuffix(buf.String(), "\n")
+			}
+		}
+	}
+	return redactTokens(data, fields)
+}
+
+// redactTokens redacts the values of the given fields at any depth, scanning
+// the JSON tokens of a possibly truncated document. The value of a redacted
+// field cut off by the truncation is redacted up to the end, as well as
+// the rest of the document after a syntax error.
+func redactTokens(data []byte, fields []string) string {
+	// frame represents an open JSON container
+	type frame struct {
+		object bool
+		key    bool
+	}
+
+	var buf strings.Builder
+	var stack []frame
+	last := 0
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	// value marks the end of a value in the current container
+	value := func() {
+		if n := len(stack); n > 0 && stack[n-1].object {
+			stack[n-1].key = true
+		}
+	}
+
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			buf.Write(data[last:])
+			return buf.String()
+		}
+		if err != nil {
+			buf.Write(data[last:offset])
+			buf.WriteString(Redacted)
+			return buf.String()
+		}
+
+		n := len(stack)
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			stack = append(stack, frame{object: token == json.Delim('{'), key: true})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:n-1]
+			value()
+			continue
+		}
+
+		name, ok := token.(string)
+		if n == 0 || !stack[n-1].object || !stack[n-1].key || !ok {
+			value()
+			continue
+		}
+		stack[n-1].key = false
+		if !contains(fields, name, true) {
+			continue
+		}
+
+		// Skip the field value, redacting it up to the end if truncated
+		start := int(decoder.InputOffset())
+		for start < len(data) && strings.IndexByte(" \t\r\n:", data[start]) >= 0 {
+			start++
+		}
+		buf.Write(data[last:start])
+		if start == len(data) {
+			// The value was cut off entirely
+			return buf.String()
+		}
+		buf.WriteString(`"` + Redacted + `"`)
+		if !skipValue(decoder) {
+			return buf.String()
+		}
+		last = int(decoder.InputOffset())
+		value()
+	}
+}
+
+// skipValue skips the next JSON value of the given decoder,
+// returning false if the value is incomplete.
+func skipValue(decoder *json.Decoder) bool {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return true
+		}
+	}
+}
+
+// redactValue redacts the given fields of the decoded JSON value.
+func redactValue(value interface{}, fields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if contains(fields, key, true) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactValue(field, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item, fields)
+		}
+	}
+	return value
+}
+
+// contains reports whether the given name is in the list.
+func contains(list []string, name string, fold bool) bool {
+	for _, item := range list {
+		if item == name || (fold && strings.EqualFold(item, name)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType defines the Prometheus text exposition format content type.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Collector represents the interface implemented by metrics backends.
+// Creating a metric that already exists returns the existent one.
+type Collector interface {
+	// Counter returns a counter metric with the given label names.
+	Counter(name, help string, labels ...string) Counter
+
+	// Gauge returns a gauge metric with the given label names.
+	Gauge(name, help string, labels ...string) Gauge
+
+	// Histogram returns a histogram metric with the given buckets and label names.
+	Histogram(name, help string, buckets []float64, labels ...string) Histogram
+}
+
+// Counter represents a monotonically increasing metric.
+type Counter interface {
+	// Add adds the given non-negative value to the series of the given label values.
+	Add(value float64, labels ...string)
+}
+
+// Gauge represents a metric that can go up and down.
+type Gauge interface {
+	// Add adds the given value to the series of the given label values.
+	Add(value float64, labels ...string)
+
+	// Set sets the value of the series of the given label values.
+	Set(value float64, labels ...string)
+}
+
+// Histogram represents a metric sampling observations into buckets.
+type Histogram interface {
+	// Observe adds an observation to the series of the given label values.
+	Observe(value float64, labels ...string)
+}
+
+// Registry implements an in-process Collector able to render the metrics
+// in the Prometheus text exposition format.
+// Registry implements the http.Handler interface to expose the metrics.
+type Registry struct {
+	// mtx protects families
+	mtx sync.Mutex
+
+	// families stores the metric families by name
+	families map[string]*family
+}
+
+// NewRegistry creates a new empty metrics registry.
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]*family{}}
+}
+
+// Counter implements the Collector interface.
+func (r *Registry) Counter(name, help string, labels ...string) Counter {
+	return r.family("counter", name, help, nil, labels)
+}
+
+// Gauge implements the Collector interface.
+func (r *Registry) Gauge(name, help string, labels ...string) Gauge {
+	return r.family("gauge", name, help, nil, labels)
+}
+
+// Histogram implements the Collector interface.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) Histogram {
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+	return r.family("histogram", name, help, sorted, labels)
+}
+
+// family returns the metric family of the given name, creating it if needed.
+// It panics if the existent family has a different type or labels.
+func (r *Registry) family(kind, name, help string, buckets []float64, labels []string) *family {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		if f.kind != kind || strings.Join(f.labels, ",") != strings.Join(labels, ",") {
+			panic(fmt.Sprintf("metrics: %s already registered with a different type or labels", name))
+		}
+		return f
+	}
+
+	f := &family{kind: kind, name: name, help: help, buckets: buckets, labels: labels, series: map[string]*series{}}
+	r.families[name] = f
+	return f
+}
+
+// ServeHTTP implements the http.Handler interface, rendering the metrics.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mtx.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mtx.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(cw)
+	}
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+// family represents a metric family with its series.
+type family struct {
+	mtx     sync.Mutex
+	kind    string
+	name    string
+	help    string
+	buckets []float64
+	labels  []string
+	series  map[string]*series
+}
+
+// series represents the values of a metric for a set of label values.
+type series struct {
+	labels []string
+	value  float64
+	counts []uint64
+	count  uint64
+}
+
+// Add implements the Counter and Gauge interfaces.
+func (f *family) Add(value float64, labels ...string) {
+	if f.kind == "counter" && value < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", f.name))
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.get(labels).value += value
+}
+
+// Set implements the Gauge interface.
+func (f *family) Set(value float64, labels ...string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.get(labels).value = value
+}
+
+// Observe implements the Histogram interface.
+func (f *family) Observe(value float64, labels ...string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	s := f.get(labels)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(f.buckets))
+	}
+	for i, bound := range f.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+// get returns the series of the given label values, creating it if needed.
+func (f *family) get(labels []string) *series {
+	if len(labels) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(labels)))
+	}
+
+	key := strings.Join(labels, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: append([]string{}, labels...)}
+		f.series[key] = s
+	}
+	return s
+}
+
+// write writes the family in the Prometheus text exposition format.
+func (f *family) write(w *countWriter) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w.printf("# HELP %s %s\n", f.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(f.help))
+	w.printf("# TYPE %s %s\n", f.name, f.kind)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != "histogram" {
+			w.printf("%s%s %s\n", f.name, f.labelSet(s.labels, ""), formatFloat(s.value))
+			continue
+		}
+
+		counts := s.counts
+		if counts == nil {
+			counts = make([]uint64, len(f.buckets))
+		}
+		for i, bound := range f.buckets {
+			w.printf("%s_bucket%s %d\n", f.name, f.labelSet(s.labels, formatFloat(bound)), counts[i])
+		}
+		w.printf("%s_bucket%s %d\n", f.name, f.labelSet(s.labels, "+Inf"), s.count)
+		w.printf("%s_sum%s %s\n", f.name, f.labelSet(s.labels, ""), formatFloat(s.value))
+		w.printf("%s_count%s %d\n", f.name, f.labelSet(s.labels, ""), s.count)
+	}
+}
+
+// labelSet formats the given label values, including the le label if present.
+func (f *family) labelSet(values []string, le string) string {
+	pairs := []string{}
+	for i, name := range f.labels {
+		pairs = append(pairs, name+`="`+escape(values[i])+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escape escapes a label value.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat formats a sample value.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countWriter counts the written bytes, keeping the first error.
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// printf writes the formatted string unless a previous write failed.
+func (w *countWriter) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
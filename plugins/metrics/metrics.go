@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+const (
+	// RouteKey stores the context store key of the route template name.
+	RouteKey = "$metrics.route"
+
+	// startKey stores the context store key of the request start time.
+	startKey = "$metrics.start"
+)
+
+var (
+	// DefaultRegistry stores the registry used by default.
+	DefaultRegistry = NewRegistry()
+
+	// DurationBuckets defines the default request duration buckets in seconds.
+	DurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// SizeBuckets defines the default response size buckets in bytes.
+	SizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7, 1e8}
+)
+
+// Options represents the metrics plugin options.
+type Options struct {
+	// Collector defines the metrics backend. Defaults to DefaultRegistry.
+	Collector Collector
+
+	// Namespace defines the metric names prefix. Defaults to gentleman.
+	Namespace string
+
+	// DurationBuckets defines the request duration buckets. Defaults to DurationBuckets.
+	DurationBuckets []float64
+
+	// SizeBuckets defines the response size buckets. Defaults to SizeBuckets.
+	SizeBuckets []float64
+}
+
+// instruments stores the metrics recorded by the plugin.
+type instruments struct {
+	requests Counter
+	inFlight Gauge
+	duration Histogram
+	size     Histogram
+}
+
+// Route creates a new plugin defining the route template name of the request,
+// such as /users/{id}, used as route label to avoid high cardinality paths.
+func Route(name string) p.Plugin {
+	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
+		ctx.Set(RouteKey, name)
+		h.Next(ctx)
+	})
+}
+
+// New creates a new plugin recording the request count, in-flight requests,
+// latency and response size by method, host, status class and route.
+// The latency is measured until the response headers are received,
+// while the response size is recorded once the body is fully read or closed.
+// Failed requests are recorded with the "error" status class, and requests
+// stopped by a later plugin before the dial with the "stopped" status class.
+// Intercepted requests are recorded with the status class of the injected response.
+func New(opts Options) p.Plugin {
+	if opts.Collector == nil {
+		opts.Collector = DefaultRegistry
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = "gentleman"
+	}
+	if opts.DurationBuckets == nil {
+		opts.DurationBuckets = DurationBuckets
+	}
+	if opts.SizeBuckets == nil {
+		opts.SizeBuckets = SizeBuckets
+	}
+
+	ns := opts.Namespace + "_"
+	m := &instruments{
+		requests: opts.Collector.Counter(ns+"requests_total", "Total number of HTTP requests.", "method", "host", "status", "route"),
+		inFlight: opts.Collector.Gauge(ns+"requests_in_flight", "Number of HTTP requests in flight.", "method", "host", "route"),
+		duration: opts.Collector.Histogram(ns+"request_duration_seconds", "HTTP request latency in seconds.", opts.DurationBuckets, "method", "host", "status", "route"),
+		size:     opts.Collector.Histogram(ns+"response_size_bytes", "HTTP response size in bytes.", opts.SizeBuckets, "method", "host", "status", "route"),
+	}
+
+	plu := p.New()
+	plu.SetHandler("before dial", m.start)
+	response := func(ctx *c.Context, h c.Handler) {
+		m.done(ctx, statusClass(ctx.Response.StatusCode))
+		h.Next(ctx)
+	}
+	plu.SetHandler("response", response)
+	plu.SetHandler("intercept", response)
+	plu.SetHandler("error", func(ctx *c.Context, h c.Handler) {
+		m.done(ctx, "error")
+		h.Next(ctx)
+	})
+	plu.SetHandler("stop", func(ctx *c.Context, h c.Handler) {
+		m.done(ctx, "stopped")
+		h.Next(ctx)
+	})
+	return plu
+}
+
+// start records the request start and increments the in-flight gauge.
+func (m *instruments) start(ctx *c.Context, h c.Handler) {
+	ctx.Set(startKey, time.Now())
+	m.inFlight.Add(1, ctx.Request.Method, ctx.Request.URL.Host, ctx.GetString(RouteKey))
+	h.Next(ctx)
+}
+
+// done records the request outcome, once per started request.
+func (m *instruments) done(ctx *c.Context, status string) {
+	start, ok := ctx.Get(startKey).(time.Time)
+	if !ok {
+		return
+	}
+	ctx.Delete(startKey)
+
+	method, host, route := ctx.Request.Method, ctx.Request.URL.Host, ctx.GetString(RouteKey)
+	m.inFlight.Add(-1, method, host, route)
+	m.requests.Add(1, method, host, status, route)
+	m.duration.Observe(time.Since(start).Seconds(), method, host, status, route)
+
+	if status == "error" || status == "stopped" || ctx.Response.Body == nil {
+		return
+	}
+	ctx.Response.Body = &sizeBody{ReadCloser: ctx.Response.Body, observe: func(size int64) {
+		m.size.Observe(float64(size), method, host, status, route)
+	}}
+}
+
+// statusClass returns the status class label of the given status code, such as 2xx.
+func statusClass(code int) string {
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// sizeBody counts the response body bytes, observing
+// the size once the body is fully read or closed.
+type sizeBody struct {
+	io.ReadCloser
+	size    int64
+	once    sync.Once
+	observe func(int64)
+}
+
+// Read implements the io.Reader interface.
+func (b *sizeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if err == io.EOF {
+		b.once.Do(func() { b.observe(b.size) })
+	}
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (b *sizeBody) Close() error {
+	b.once.Do(func() { b.observe(b.size) })
+	return b.ReadCloser.Close()
+}
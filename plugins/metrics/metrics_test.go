@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lytics/gentleman"
+	c "github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	reg.Counter("jobs_total", "Total jobs.", "queue").Add(2, `a"b`)
+	reg.Gauge("temperature", "Current\ntemperature.").Set(21.5)
+	h := reg.Histogram("latency_seconds", "Latency.", []float64{1, 0.5}, "op")
+	h.Observe(0.2, "get")
+	h.Observe(0.7, "get")
+	h.Observe(3, "get")
+
+	utils.Equal(t, reg.Counter("jobs_total", "Total jobs.", "queue"), reg.Counter("jobs_total", "", "queue"))
+
+	var buf bytes.Buffer
+	n, err := reg.WriteTo(&buf)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, n, int64(buf.Len()))
+	utils.Equal(t, buf.String(), `# HELP jobs_total Total jobs.
+# TYPE jobs_total counter
+jobs_total{queue="a\"b"} 2
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="get",le="0.5"} 1
+latency_seconds_bucket{op="get",le="1"} 2
+latency_seconds_bucket{op="get",le="+Inf"} 3
+latency_seconds_sum{op="get"} 3.9
+latency_seconds_count{op="get"} 3
+# HELP temperature Current\ntemperature.
+# TYPE temperature gauge
+temperature 21.5
+`)
+}
+
+func TestRegistryMismatch(t *testing.T) {
+	reg := NewRegistry()
+	reg.Counter("requests", "", "method")
+
+	defer func() {
+		utils.NotEqual(t, recover(), nil)
+	}()
+	reg.Gauge("requests", "", "method")
+}
+
+func TestRegistryHandler(t *testing.T) {
+	reg := NewRegistry()
+	reg.Counter("hits_total", "Hits.").Add(1)
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	utils.Equal(t, rec.Header().Get("Content-Type"), ContentType)
+	utils.Equal(t, rec.Body.String(), "# HELP hits_total Hits.\n# TYPE hits_total counter\nhits_total 1\n")
+}
+
+func TestMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(404)
+			return
+		}
+		fmt.Fprint(w, "hello world")
+	}))
+	defer ts.Close()
+
+	reg := NewRegistry()
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Collector: reg, Namespace: "api"}))
+
+	res, err := cli.Request().Path("/users/1").Use(Route("/users/{id}")).Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "hello world")
+
+	res, err = cli.Request().Path("/missing").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 404)
+	res.Close()
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	output := render(reg)
+	utils.Equal(t, strings.Contains(output, `api_requests_total{method="GET",host="`+host+`",status="2xx",route="/users/{id}"} 1`), true)
+	utils.Equal(t, strings.Contains(output, `api_requests_total{method="GET",host="`+host+`",status="4xx",route=""} 1`), true)
+	utils.Equal(t, strings.Contains(output, `api_requests_in_flight{method="GET",host="`+host+`",route="/users/{id}"} 0`), true)
+	utils.Equal(t, strings.Contains(output, `api_request_duration_seconds_count{method="GET",host="`+host+`",status="2xx",route="/users/{id}"} 1`), true)
+	utils.Equal(t, strings.Contains(output, `api_response_size_bytes_sum{method="GET",host="`+host+`",status="2xx",route="/users/{id}"} 11`), true)
+	utils.Equal(t, strings.Contains(output, `api_response_size_bytes_sum{method="GET",host="`+host+`",status="4xx",route=""} 0`), true)
+}
+
+func TestMetricsInFlight(t *testing.T) {
+	reg := NewRegistry()
+	var inFlight string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight = render(reg)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Collector: reg}))
+	_, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	utils.Equal(t, strings.Contains(inFlight, `gentleman_requests_in_flight{method="GET",host="`+host+`",route=""} 1`), true)
+	utils.Equal(t, strings.Contains(render(reg), `gentleman_requests_in_flight{method="GET",host="`+host+`",route=""} 0`), true)
+}
+
+func TestMetricsError(t *testing.T) {
+	reg := NewRegistry()
+	cli := gentleman.New().URL("http://example.com")
+	cli.Use(New(Options{Collector: reg}))
+	cli.UseHandler("before dial", func(ctx *c.Context, h c.Handler) {
+		ctx.Client.Transport = failTransport{}
+		h.Next(ctx)
+	})
+
+	_, err := cli.Request().Send()
+	utils.NotEqual(t, err, nil)
+
+	output := render(reg)
+	utils.Equal(t, strings.Contains(output, `gentleman_requests_total{method="GET",host="example.com",status="error",route=""} 1`), true)
+	utils.Equal(t, strings.Contains(output, `gentleman_requests_in_flight{method="GET",host="example.com",route=""} 0`), true)
+	utils.Equal(t, strings.Contains(output, "gentleman_response_size_bytes_count"), false)
+}
+
+func TestMetricsStopped(t *testing.T) {
+	reg := NewRegistry()
+	cli := gentleman.New().URL("http://example.com")
+	cli.Use(New(Options{Collector: reg}))
+	cli.UseHandler("before dial", func(ctx *c.Context, h c.Handler) {
+		h.Stop(ctx)
+	})
+
+	_, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+
+	output := render(reg)
+	utils.Equal(t, strings.Contains(output, `gentleman_requests_total{method="GET",host="example.com",status="stopped",route=""} 1`), true)
+	utils.Equal(t, strings.Contains(output, `gentleman_requests_in_flight{method="GET",host="example.com",route=""} 0`), true)
+	utils.Equal(t, strings.Contains(output, `gentleman_request_duration_seconds_count{method="GET",host="example.com",status="stopped",route=""} 1`), true)
+}
+
+func TestMetricsIntercepted(t *testing.T) {
+	reg := NewRegistry()
+	cli := gentleman.New().URL("http://example.com")
+	cli.Use(New(Options{Collector: reg}))
+	cli.UseHandler("before dial", func(ctx *c.Context, h c.Handler) {
+		utils.ReplyWithStatus(ctx.Response, 200)
+		utils.WriteBodyString(ctx.Response, "cached")
+		h.Next(ctx)
+	})
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "cached")
+
+	output := render(reg)
+	utils.Equal(t, strings.Contains(output, `gentleman_requests_total{method="GET",host="example.com",status="2xx",route=""} 1`), true)
+	utils.Equal(t, strings.Contains(output, `gentleman_requests_in_flight{method="GET",host="example.com",route=""} 0`), true)
+	utils.Equal(t, strings.Contains(output, `gentleman_response_size_bytes_sum{method="GET",host="example.com",status="2xx",route=""} 6`), true)
+}
+
+func TestStatusClass(t *testing.T) {
+	utils.Equal(t, statusClass(200), "2xx")
+	utils.Equal(t, statusClass(302), "3xx")
+	utils.Equal(t, statusClass(503), "5xx")
+}
+
+type failTransport struct{}
+
+func (failTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, &url.Error{Op: "Get", URL: req.URL.String(), Err: errors.New("connection refused")}
+}
+
+func render(reg *Registry) string {
+	var buf bytes.Buffer
+	reg.WriteTo(&buf)
+	return buf.String()
+}
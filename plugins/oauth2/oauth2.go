@@ -0,0 +1,74 @@
+package oauth2
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// New creates a new OAuth2 plugin authorizing requests with tokens
+// obtained from the given source. If the server replies with 401 Unauthorized,
+// the token is invalidated and the request is replayed once with a new token.
+// The token source should be shared by all the requests of a Client,
+// so it is recommended to use the plugin at client level.
+func New(source *Source) p.Plugin {
+	return p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		ctx.Client.Transport = &Transport{source, ctx.Client.Transport, ctx}
+		h.Next(ctx)
+	})
+}
+
+// Transport provides a http.RoundTripper compatible transport who encapsulates
+// the original http.Transport and authorizes the outgoing requests.
+type Transport struct {
+	source    *Source
+	transport http.RoundTripper
+	context   *c.Context
+}
+
+// RoundTrip implements the required method by http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Restore original http.Transport
+	t.context.Client.Transport = t.transport
+
+	// Cache the body buffer to be able to replay the request
+	var buf []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if buf, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	token, res, err := t.send(req, buf)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	// Replay the request once with a new token
+	t.source.Invalidate(token)
+	res.Body.Close()
+	_, res, err = t.send(req, buf)
+	return res, err
+}
+
+// send sends a copy of the given request authorized with the current token.
+func (t *Transport) send(req *http.Request, buf []byte) (*Token, *http.Response, error) {
+	token, err := t.source.Token(req.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reqCopy := req.Clone(req.Context())
+	reqCopy.Header.Set("Authorization", token.Authorization())
+	if buf != nil {
+		reqCopy.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	}
+
+	res, err := t.transport.RoundTrip(reqCopy)
+	return token, res, err
+}
@@ -0,0 +1,197 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman"
+	"github.com/lytics/gentleman/utils"
+)
+
+func newTokenServer(t *testing.T, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		r.ParseForm()
+
+		user, pass, _ := r.BasicAuth()
+		if user != "id" || pass != "secret" {
+			w.WriteHeader(401)
+			fmt.Fprint(w, `{"error":"invalid_client","error_description":"bad credentials"}`)
+			return
+		}
+
+		switch r.Form.Get("grant_type") {
+		case GrantClientCredentials:
+			utils.Equal(t, r.Form.Get("scope"), "read write")
+		case GrantRefreshToken:
+			if r.Form.Get("refresh_token") != fmt.Sprintf("refresh-%d", n-1) {
+				w.WriteHeader(400)
+				fmt.Fprint(w, `{"error":"invalid_grant"}`)
+				return
+			}
+		case GrantJWTBearer:
+			utils.Equal(t, r.Form.Get("assertion"), "jwt")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600,"refresh_token":"refresh-%d"}`, n, n)
+	}))
+}
+
+func TestClientCredentials(t *testing.T) {
+	var calls int32
+	ts := newTokenServer(t, &calls)
+	defer ts.Close()
+
+	source := ClientCredentials(Config{TokenURL: ts.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read", "write"}})
+
+	token, err := source.Token(context.Background())
+	utils.Equal(t, err, nil)
+	utils.Equal(t, token.AccessToken, "token-1")
+	utils.Equal(t, token.Authorization(), "Bearer token-1")
+	utils.Equal(t, token.Valid(), true)
+
+	// Cached token
+	token, _ = source.Token(context.Background())
+	utils.Equal(t, token.AccessToken, "token-1")
+	utils.Equal(t, atomic.LoadInt32(&calls), int32(1))
+
+	// Invalidated tokens are renewed via the refresh token grant
+	source.Invalidate(token)
+	token, err = source.Token(context.Background())
+	utils.Equal(t, err, nil)
+	utils.Equal(t, token.AccessToken, "token-2")
+}
+
+func TestSourceConcurrentRefresh(t *testing.T) {
+	var calls int32
+	ts := newTokenServer(t, &calls)
+	defer ts.Close()
+
+	source := JWTBearer(Config{TokenURL: ts.URL, ClientID: "id", ClientSecret: "secret"}, func() (string, error) {
+		return "jwt", nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := source.Token(context.Background())
+			utils.Equal(t, err, nil)
+			utils.Equal(t, token.AccessToken, "token-1")
+		}()
+	}
+	wg.Wait()
+	utils.Equal(t, atomic.LoadInt32(&calls), int32(1))
+}
+
+func TestSourceRefreshTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { RefreshTimeout = timeout }(RefreshTimeout)
+	RefreshTimeout = 50 * time.Millisecond
+
+	// Hangs until the token request is aborted or the test ends
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	source := ClientCredentials(Config{TokenURL: ts.URL, ClientID: "id", ClientSecret: "secret"})
+	start := time.Now()
+	_, err := source.Token(context.Background())
+	utils.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	utils.Equal(t, time.Since(start) < time.Second, true)
+}
+
+func TestRefreshToken(t *testing.T) {
+	var calls int32
+	ts := newTokenServer(t, &calls)
+	defer ts.Close()
+
+	atomic.StoreInt32(&calls, 1)
+	source := RefreshToken(Config{TokenURL: ts.URL, ClientID: "id", ClientSecret: "secret"}, "refresh-1")
+
+	token, err := source.Token(context.Background())
+	utils.Equal(t, err, nil)
+	utils.Equal(t, token.AccessToken, "token-2")
+	utils.Equal(t, token.RefreshToken, "refresh-2")
+
+	// Rotated refresh token is used
+	source.Invalidate(token)
+	token, err = source.Token(context.Background())
+	utils.Equal(t, err, nil)
+	utils.Equal(t, token.AccessToken, "token-3")
+}
+
+func TestTokenError(t *testing.T) {
+	var calls int32
+	ts := newTokenServer(t, &calls)
+	defer ts.Close()
+
+	source := ClientCredentials(Config{TokenURL: ts.URL, ClientID: "id", ClientSecret: "invalid"})
+	_, err := source.Token(context.Background())
+	utils.Equal(t, err.Error(), "oauth2: token request failed with status 401: invalid_client: bad credentials")
+
+	oerr, ok := err.(*Error)
+	utils.Equal(t, ok, true)
+	utils.Equal(t, oerr.Code, "invalid_client")
+}
+
+func TestTokenValid(t *testing.T) {
+	var token *Token
+	utils.Equal(t, token.Valid(), false)
+
+	token = &Token{AccessToken: "foo"}
+	utils.Equal(t, token.Valid(), true)
+
+	token.Expiry = time.Now().Add(ExpiryDelta / 2)
+	utils.Equal(t, token.Valid(), false)
+
+	token.TokenType = "MAC"
+	utils.Equal(t, token.Authorization(), "MAC foo")
+}
+
+func TestPluginReplay(t *testing.T) {
+	var calls int32
+	tokens := newTokenServer(t, &calls)
+	defer tokens.Close()
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(401)
+			return
+		}
+		buf := make([]byte, 3)
+		r.Body.Read(buf)
+		fmt.Fprintf(w, "%s %s", r.Header.Get("Authorization"), buf)
+	}))
+	defer ts.Close()
+
+	source := ClientCredentials(Config{TokenURL: tokens.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read", "write"}})
+	cli := gentleman.New().URL(ts.URL).Use(New(source))
+
+	res, err := cli.Request().Method("POST").BodyString("foo").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "Bearer token-2 foo")
+	utils.Equal(t, atomic.LoadInt32(&requests), int32(2))
+
+	res, err = cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, atomic.LoadInt32(&calls), int32(2))
+}
@@ -0,0 +1,165 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RefreshTimeout defines the maximum time to obtain a new token.
+// Token refreshes are shared by concurrent callers, so they are not
+// canceled with the context of the initiating caller, but bounded by this timeout.
+var RefreshTimeout = 30 * time.Second
+
+// FetchFunc obtains a new token, given the current one, if any.
+type FetchFunc func(ctx context.Context, current *Token) (*Token, error)
+
+// AssertionFunc returns a signed JWT used as authorization grant.
+type AssertionFunc func() (string, error)
+
+// Source caches tokens until shortly before their expiry.
+// Concurrent requests share a single in-flight token refresh.
+type Source struct {
+	// fetch stores the function used to obtain new tokens
+	fetch FetchFunc
+
+	// mtx protects the fields below
+	mtx sync.Mutex
+
+	// token stores the cached token
+	token *Token
+
+	// pending stores the in-flight token refresh
+	pending *refresh
+}
+
+// refresh represents an in-flight token refresh.
+type refresh struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// NewSource creates a new caching token source based on the given fetch function.
+func NewSource(fetch FetchFunc) *Source {
+	return &Source{fetch: fetch}
+}
+
+// ClientCredentials creates a token source using the client credentials grant.
+// Tokens including a refresh token are renewed via the refresh token grant,
+// falling back to the client credentials grant.
+func ClientCredentials(cfg Config) *Source {
+	return NewSource(withRefresh(cfg, func(ctx context.Context) (*Token, error) {
+		return cfg.Exchange(ctx, GrantClientCredentials, nil)
+	}))
+}
+
+// RefreshToken creates a token source using the refresh token grant
+// based on the given refresh token. Rotated refresh tokens are honored.
+func RefreshToken(cfg Config, refreshToken string) *Source {
+	return NewSource(func(ctx context.Context, current *Token) (*Token, error) {
+		if current != nil && current.RefreshToken != "" {
+			refreshToken = current.RefreshToken
+		}
+		return refreshGrant(ctx, cfg, refreshToken)
+	})
+}
+
+// JWTBearer creates a token source using the JWT bearer grant (RFC 7523),
+// calling the given function to create a signed assertion on each request.
+func JWTBearer(cfg Config, assertion AssertionFunc) *Source {
+	return NewSource(withRefresh(cfg, func(ctx context.Context) (*Token, error) {
+		jwt, err := assertion()
+		if err != nil {
+			return nil, err
+		}
+		return cfg.Exchange(ctx, GrantJWTBearer, url.Values{"assertion": {jwt}})
+	}))
+}
+
+// Token returns a valid token, obtaining a new one if needed.
+func (s *Source) Token(ctx context.Context) (*Token, error) {
+	s.mtx.Lock()
+	if s.token.Valid() {
+		token := s.token
+		s.mtx.Unlock()
+		return token, nil
+	}
+
+	call := s.pending
+	if call == nil {
+		call = &refresh{done: make(chan struct{})}
+		s.pending = call
+		go s.refresh(ctx, call, s.token)
+	}
+	s.mtx.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Invalidate discards the given token if it is still the cached one,
+// forcing the next call to obtain a new token.
+func (s *Source) Invalidate(token *Token) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.token == token && token != nil {
+		// Keep the refresh token, if any, to renew the access token
+		s.token = &Token{RefreshToken: token.RefreshToken}
+	}
+}
+
+// refresh obtains a new token, sharing the result with all the waiting callers.
+// The refresh is not canceled if the context of the initiating caller is canceled,
+// but it fails once RefreshTimeout is exceeded.
+func (s *Source) refresh(ctx context.Context, call *refresh, current *Token) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), RefreshTimeout)
+	defer cancel()
+
+	call.token, call.err = s.fetch(ctx, current)
+	if call.err == nil && call.token == nil {
+		call.err = errors.New("oauth2: no token returned")
+	}
+
+	s.mtx.Lock()
+	if call.err == nil {
+		s.token = call.token
+	}
+	s.pending = nil
+	s.mtx.Unlock()
+
+	close(call.done)
+}
+
+// withRefresh returns a fetch function renewing tokens via the refresh token
+// grant when possible, falling back to the given grant.
+func withRefresh(cfg Config, grant func(context.Context) (*Token, error)) FetchFunc {
+	return func(ctx context.Context, current *Token) (*Token, error) {
+		if current != nil && current.RefreshToken != "" {
+			if token, err := refreshGrant(ctx, cfg, current.RefreshToken); err == nil {
+				return token, nil
+			}
+		}
+		return grant(ctx)
+	}
+}
+
+// refreshGrant obtains a new token via the refresh token grant,
+// keeping the current refresh token if the server does not rotate it.
+func refreshGrant(ctx context.Context, cfg Config, refreshToken string) (*Token, error) {
+	token, err := cfg.Exchange(ctx, GrantRefreshToken, url.Values{"refresh_token": {refreshToken}})
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
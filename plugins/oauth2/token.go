@@ -0,0 +1,196 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ExpiryDelta defines how long before the expiry a token is considered expired,
+// so it is refreshed shortly before the server rejects it.
+var ExpiryDelta = 10 * time.Second
+
+// Grant types.
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+	GrantJWTBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+)
+
+// Token represents an OAuth2 access token.
+type Token struct {
+	// AccessToken stores the token used to authorize requests.
+	AccessToken string
+
+	// TokenType stores the token type, usually Bearer.
+	TokenType string
+
+	// RefreshToken stores the optional token used to obtain new access tokens.
+	RefreshToken string
+
+	// Scope stores the scope granted by the server.
+	Scope string
+
+	// Expiry stores when the access token expires.
+	// Zero means the token does not expire.
+	Expiry time.Time
+}
+
+// Valid returns true if the token is present and not about to expire.
+func (t *Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(ExpiryDelta).Before(t.Expiry)
+}
+
+// Authorization returns the Authorization header value for the token.
+func (t *Token) Authorization() string {
+	kind := t.TokenType
+	if kind == "" || strings.EqualFold(kind, "bearer") {
+		kind = "Bearer"
+	}
+	return kind + " " + t.AccessToken
+}
+
+// Error represents an error response from the token endpoint.
+type Error struct {
+	// StatusCode stores the token endpoint response status code.
+	StatusCode int
+
+	// Code stores the OAuth2 error code, such as invalid_grant.
+	Code string `json:"error"`
+
+	// Description stores the optional human-readable error description.
+	Description string `json:"error_description"`
+
+	// URI stores the optional error information web page.
+	URI string `json:"error_uri"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("oauth2: token request failed with status %d", e.StatusCode)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// Config represents the OAuth2 client and token endpoint configuration.
+type Config struct {
+	// TokenURL defines the token endpoint URL.
+	TokenURL string
+
+	// ClientID defines the client identifier.
+	ClientID string
+
+	// ClientSecret defines the client secret.
+	ClientSecret string
+
+	// Scopes defines the requested scopes.
+	Scopes []string
+
+	// Params defines additional token request parameters, such as audience.
+	Params url.Values
+
+	// AuthInParams sends the client credentials in the request body
+	// instead of using HTTP Basic authentication.
+	AuthInParams bool
+
+	// Client defines the HTTP client used to request tokens.
+	// Defaults to http.DefaultClient. Requests are bounded by the given context,
+	// which Source limits to RefreshTimeout.
+	Client *http.Client
+}
+
+// tokenResponse represents the token endpoint JSON response.
+type tokenResponse struct {
+	AccessToken  string      `json:"access_token"`
+	TokenType    string      `json:"token_type"`
+	RefreshToken string      `json:"refresh_token"`
+	Scope        string      `json:"scope"`
+	ExpiresIn    json.Number `json:"expires_in"`
+}
+
+// Exchange requests a new token from the token endpoint with the given grant type and parameters.
+func (c *Config) Exchange(ctx context.Context, grantType string, params url.Values) (*Token, error) {
+	form := url.Values{}
+	for key, values := range c.Params {
+		form[key] = values
+	}
+	for key, values := range params {
+		form[key] = values
+	}
+	form.Set("grant_type", grantType)
+	if len(c.Scopes) > 0 && grantType != GrantRefreshToken {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	if c.AuthInParams {
+		form.Set("client_id", c.ClientID)
+		if c.ClientSecret != "" {
+			form.Set("client_secret", c.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !c.AuthInParams && c.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		oerr := &Error{}
+		json.Unmarshal(body, oerr)
+		oerr.StatusCode = res.StatusCode
+		return nil, oerr
+	}
+
+	data := &tokenResponse{}
+	if err := json.Unmarshal(body, data); err != nil {
+		return nil, fmt.Errorf("oauth2: cannot decode token response: %w", err)
+	}
+	if data.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2: token response without access_token")
+	}
+
+	token := &Token{
+		AccessToken:  data.AccessToken,
+		TokenType:    data.TokenType,
+		RefreshToken: data.RefreshToken,
+		Scope:        data.Scope,
+	}
+	if seconds, err := data.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		token.Expiry = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return token, nil
+}
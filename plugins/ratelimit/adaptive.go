@@ -0,0 +1,271 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+	"github.com/lytics/gentleman/plugins/retry"
+	"github.com/lytics/gentleman/plugins/retry/retrier"
+)
+
+// Quota represents the rate limit quota announced by a server.
+type Quota struct {
+	// Limit stores the maximum number of requests allowed in the window,
+	// or -1 if unknown.
+	Limit int
+
+	// Remaining stores the number of requests left in the current window,
+	// or -1 if unknown.
+	Remaining int
+
+	// Reset stores when the current window resets.
+	Reset time.Time
+
+	// Updated stores when the quota was last updated.
+	Updated time.Time
+}
+
+// Wait returns the time to wait until requests are allowed again.
+func (q Quota) Wait(now time.Time) time.Duration {
+	if q.Remaining != 0 || !q.Reset.After(now) {
+		return 0
+	}
+	return q.Reset.Sub(now)
+}
+
+// AdaptiveOptions represents the adaptive throttling plugin options.
+type AdaptiveOptions struct {
+	// FailFast reports a LimitError instead of waiting
+	// for the quota window to reset.
+	FailFast bool
+
+	// MaxWait defines the maximum time to wait for the quota window to reset.
+	// Requests that would wait longer fail with a LimitError.
+	// Zero means no limit.
+	MaxWait time.Duration
+}
+
+// Throttler delays or rejects requests per host based on the rate limit
+// quota announced by the server via the RateLimit-Limit, RateLimit-Remaining
+// and RateLimit-Reset headers, their X-RateLimit-* variants and Retry-After.
+// It implements the plugin interface.
+type Throttler struct {
+	p.Plugin
+
+	// opts stores the throttler options
+	opts AdaptiveOptions
+
+	// mtx protects quotas
+	mtx sync.Mutex
+
+	// quotas stores the known quotas by host
+	quotas map[string]*Quota
+}
+
+// Adaptive creates a new adaptive throttling plugin with the given options.
+func Adaptive(opts AdaptiveOptions) *Throttler {
+	t := &Throttler{opts: opts, quotas: map[string]*Quota{}}
+
+	plu := p.New()
+	plu.SetHandler("before dial", t.throttle)
+	plu.SetHandler("response", func(ctx *c.Context, h c.Handler) {
+		t.Update(ctx.Request.URL.Host, ctx.Response)
+		h.Next(ctx)
+	})
+	t.Plugin = plu
+	return t
+}
+
+// Quota returns the current quota for the given host.
+func (t *Throttler) Quota(host string) (Quota, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	quota, ok := t.quotas[host]
+	if !ok {
+		return Quota{}, false
+	}
+	return *quota, true
+}
+
+// Quotas returns a snapshot of the current quotas by host.
+func (t *Throttler) Quotas() map[string]Quota {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	quotas := make(map[string]Quota, len(t.quotas))
+	for host, quota := range t.quotas {
+		quotas[host] = *quota
+	}
+	return quotas
+}
+
+// Update updates the quota of the given host based on the response headers.
+func (t *Throttler) Update(host string, res *http.Response) {
+	now := time.Now()
+	quota, ok := parseQuota(res, now)
+	if !ok {
+		return
+	}
+
+	t.mtx.Lock()
+	t.quotas[host] = &quota
+	t.mtx.Unlock()
+}
+
+// Evaluator returns a retry plugin evaluator function based on the given one,
+// which defaults to retry.Evaluator. Failed responses with a Retry-After
+// header are reported as LimitError wrapping the evaluated error, so the
+// retrier waits exactly as long as the server asks. The host quota is updated on every attempt.
+// In fail fast mode, or if the server asks to wait longer than MaxWait,
+// the LimitError is wrapped via retrier.Permanent to stop retrying.
+func (t *Throttler) Evaluator(next retry.EvalFunc) retry.EvalFunc {
+	if next == nil {
+		next = retry.Evaluator
+	}
+
+	return func(err error, res *http.Response, req *http.Request) error {
+		if err != nil || res == nil {
+			return next(err, res, req)
+		}
+
+		t.Update(req.URL.Host, res)
+		if err = next(err, res, req); err == nil {
+			return nil
+		}
+
+		if wait, ok := retryAfter(res, time.Now()); ok {
+			err = &LimitError{Key: req.URL.Host, RetryAfter: wait, Err: err}
+			if !t.canWait(wait) {
+				return retrier.Permanent(err)
+			}
+		}
+		return err
+	}
+}
+
+// throttle waits or fails until the quota of the target host allows the request.
+func (t *Throttler) throttle(ctx *c.Context, h c.Handler) {
+	host := ctx.Request.URL.Host
+	wait := t.reserve(host, time.Now())
+	if wait == 0 {
+		h.Next(ctx)
+		return
+	}
+
+	if !t.canWait(wait) {
+		h.Error(ctx, &LimitError{Key: host, RetryAfter: wait})
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		h.Next(ctx)
+	case <-ctx.Done():
+		h.Error(ctx, ctx.Err())
+	}
+}
+
+// canWait returns true if requests are allowed to wait the given time
+// for the quota window to reset.
+func (t *Throttler) canWait(wait time.Duration) bool {
+	return !t.opts.FailFast && (t.opts.MaxWait <= 0 || wait <= t.opts.MaxWait)
+}
+
+// reserve returns the time to wait before dialing the given host,
+// consuming one request of the known remaining quota.
+func (t *Throttler) reserve(host string, now time.Time) time.Duration {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	quota, ok := t.quotas[host]
+	if !ok {
+		return 0
+	}
+	if wait := quota.Wait(now); wait > 0 {
+		return wait
+	}
+	if quota.Remaining > 0 && quota.Reset.After(now) {
+		quota.Remaining--
+	}
+	return 0
+}
+
+// parseQuota parses the rate limit quota from the given response headers.
+func parseQuota(res *http.Response, now time.Time) (Quota, bool) {
+	quota := Quota{Limit: -1, Remaining: -1, Updated: now}
+	found := false
+
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-"} {
+		if limit, ok := headerInt(res.Header, prefix+"Limit"); ok {
+			quota.Limit, found = limit, true
+		}
+		if remaining, ok := headerInt(res.Header, prefix+"Remaining"); ok {
+			quota.Remaining, found = remaining, true
+		}
+		if reset, ok := headerInt(res.Header, prefix+"Reset"); ok {
+			quota.Reset, found = resetTime(reset, now), true
+		}
+		if found {
+			break
+		}
+	}
+
+	if wait, ok := retryAfter(res, now); ok {
+		quota.Remaining = 0
+		quota.Reset = now.Add(wait)
+		found = true
+	}
+
+	return quota, found
+}
+
+// retryAfter parses the Retry-After header of throttled responses,
+// either in delay seconds or HTTP date format.
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(res.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// resetTime returns the window reset time based on the given header value,
+// either in delay seconds or as Unix timestamp, as used by some providers.
+func resetTime(value int, now time.Time) time.Time {
+	if int64(value) > now.Unix()/2 {
+		return time.Unix(int64(value), 0)
+	}
+	return now.Add(time.Duration(value) * time.Second)
+}
+
+// headerInt parses the given header as a non-negative integer.
+func headerInt(header http.Header, name string) (int, bool) {
+	value := strings.TrimSpace(header.Get(name))
+	if i := strings.IndexAny(value, ",;"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil && n >= 0
+}
@@ -0,0 +1,201 @@
+package ratelimit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman"
+	"github.com/lytics/gentleman/plugins/retry"
+	"github.com/lytics/gentleman/plugins/retry/retrier"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestParseQuota(t *testing.T) {
+	now := time.Now()
+
+	res := &http.Response{StatusCode: 200, Header: http.Header{}}
+	res.Header.Set("RateLimit-Limit", "100")
+	res.Header.Set("RateLimit-Remaining", "10")
+	res.Header.Set("RateLimit-Reset", "30")
+
+	quota, ok := parseQuota(res, now)
+	utils.Equal(t, ok, true)
+	utils.Equal(t, quota.Limit, 100)
+	utils.Equal(t, quota.Remaining, 10)
+	utils.Equal(t, quota.Reset, now.Add(30*time.Second))
+	utils.Equal(t, quota.Wait(now), time.Duration(0))
+
+	reset := now.Add(time.Minute).Unix()
+	res = &http.Response{StatusCode: 200, Header: http.Header{}}
+	res.Header.Set("X-RateLimit-Remaining", "0")
+	res.Header.Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+
+	quota, ok = parseQuota(res, now)
+	utils.Equal(t, ok, true)
+	utils.Equal(t, quota.Limit, -1)
+	utils.Equal(t, quota.Reset, time.Unix(reset, 0))
+	utils.Equal(t, quota.Wait(now) > 55*time.Second, true)
+
+	res = &http.Response{StatusCode: 429, Header: http.Header{}}
+	res.Header.Set("Retry-After", now.Add(2*time.Minute).UTC().Format(http.TimeFormat))
+
+	quota, ok = parseQuota(res, now)
+	utils.Equal(t, ok, true)
+	utils.Equal(t, quota.Remaining, 0)
+	utils.Equal(t, quota.Wait(now) > time.Minute, true)
+
+	_, ok = parseQuota(&http.Response{StatusCode: 200, Header: http.Header{}}, now)
+	utils.Equal(t, ok, false)
+}
+
+func TestAdaptiveFailFast(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(429)
+	}))
+	defer ts.Close()
+
+	throttler := Adaptive(AdaptiveOptions{FailFast: true})
+	cli := gentleman.New().URL(ts.URL).Use(throttler)
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 429)
+
+	quota, ok := throttler.Quota(ts.Listener.Addr().String())
+	utils.Equal(t, ok, true)
+	utils.Equal(t, quota.Remaining, 0)
+	utils.Equal(t, len(throttler.Quotas()), 1)
+
+	_, err = cli.Request().Send()
+	var limitErr *LimitError
+	utils.Equal(t, errors.As(err, &limitErr), true)
+	utils.Equal(t, limitErr.RetryAfter > 59*time.Second, true)
+}
+
+func TestAdaptiveWait(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("RateLimit-Remaining", "0")
+			w.Header().Set("RateLimit-Reset", "1")
+		}
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL).Use(Adaptive(AdaptiveOptions{}))
+	cli.Request().Send()
+
+	start := time.Now()
+	_, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, time.Since(start) > 500*time.Millisecond, true)
+
+	// MaxWait exceeded
+	cli = gentleman.New().URL(ts.URL).Use(Adaptive(AdaptiveOptions{MaxWait: time.Millisecond}))
+	atomic.StoreInt32(&calls, 0)
+	cli.Request().Send()
+	_, err = cli.Request().Send()
+	utils.Equal(t, errors.Is(err, ErrLimited), true)
+}
+
+func TestAdaptiveEvaluator(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(429)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	throttler := Adaptive(AdaptiveOptions{})
+	backoff := retrier.New(retrier.ConstantBackoff(1, time.Minute), nil)
+
+	cli := gentleman.New().URL(ts.URL).Use(throttler)
+	cli.Use(retry.New(backoff, throttler.Evaluator(nil)))
+
+	start := time.Now()
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "hello")
+	utils.Equal(t, atomic.LoadInt32(&calls), int32(2))
+	utils.Equal(t, time.Since(start) < time.Second, true)
+
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	failed := &http.Response{StatusCode: 503, Header: http.Header{"Retry-After": []string{"5"}}}
+	err = throttler.Evaluator(nil)(nil, failed, req)
+	var delayer retrier.Delayer
+	utils.Equal(t, errors.As(err, &delayer), true)
+	utils.Equal(t, delayer.Delay(), 5*time.Second)
+}
+
+func TestAdaptiveEvaluatorClassifier(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	throttler := Adaptive(AdaptiveOptions{})
+	backoff := retrier.New(retrier.ConstantBackoff(1, time.Minute), retrier.WhitelistClassifier{retry.ErrServer})
+
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(retry.New(backoff, throttler.Evaluator(nil)))
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, atomic.LoadInt32(&calls), int32(2))
+
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	failed := &http.Response{StatusCode: 503, Header: http.Header{"Retry-After": []string{"5"}}}
+	err = throttler.Evaluator(nil)(nil, failed, req)
+	utils.Equal(t, errors.Is(err, retry.ErrServer), true)
+	utils.Equal(t, errors.Is(err, ErrLimited), true)
+}
+
+func TestAdaptiveEvaluatorMaxWait(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(429)
+	}))
+	defer ts.Close()
+
+	throttler := Adaptive(AdaptiveOptions{MaxWait: time.Second})
+	backoff := retrier.New(retrier.ConstantBackoff(3, time.Millisecond), nil)
+
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(retry.New(backoff, throttler.Evaluator(nil)))
+
+	start := time.Now()
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 429)
+	utils.Equal(t, atomic.LoadInt32(&calls), int32(1))
+	utils.Equal(t, time.Since(start) < time.Second, true)
+
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	failed := &http.Response{StatusCode: 503, Header: http.Header{"Retry-After": []string{"5"}}}
+	err = Adaptive(AdaptiveOptions{FailFast: true}).Evaluator(nil)(nil, failed, req)
+	var limitErr *LimitError
+	utils.Equal(t, errors.As(err, &limitErr), true)
+	utils.Equal(t, limitErr.RetryAfter, 5*time.Second)
+	utils.Equal(t, backoff.Run(func() error { atomic.AddInt32(&calls, 1); return err }), err)
+	utils.Equal(t, atomic.LoadInt32(&calls), int32(2))
+}
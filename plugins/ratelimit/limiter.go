@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter implements a token bucket rate limiter.
+// The bucket is refilled at a constant rate up to the burst size,
+// and each request consumes one token.
+type Limiter struct {
+	// mtx protects the fields below
+	mtx sync.Mutex
+
+	// rate stores the number of tokens added per second
+	rate float64
+
+	// burst stores the bucket capacity
+	burst float64
+
+	// tokens stores the available tokens, negative if there are waiters
+	tokens float64
+
+	// last stores the last time the bucket was refilled
+	last time.Time
+}
+
+// NewLimiter creates a new token bucket limiter allowing the given
+// amount of events per second, with bursts of up to the given size.
+// The bucket starts full. Panics if the rate is not positive.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if rate <= 0 {
+		panic(fmt.Sprintf("ratelimit: rate must be positive, got %v", rate))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow consumes a token if available, returning false otherwise.
+func (l *Limiter) Allow() bool {
+	return l.Delay() == 0
+}
+
+// Delay consumes a token if available, otherwise returns
+// the amount of time to wait until the next token is available.
+func (l *Limiter) Delay() time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.refill(time.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return l.duration(1 - l.tokens)
+}
+
+// Wait blocks until a token is available or the given context is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mtx.Lock()
+	l.refill(time.Now())
+	l.tokens--
+	delay := l.duration(-l.tokens)
+	l.mtx.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give back the reserved token
+		l.mtx.Lock()
+		l.tokens = math.Min(l.tokens+1, l.burst)
+		l.mtx.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Tokens returns the number of currently available tokens.
+func (l *Limiter) Tokens() float64 {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.refill(time.Now())
+	return math.Max(l.tokens, 0)
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (l *Limiter) refill(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = math.Min(l.tokens+elapsed.Seconds()*l.rate, l.burst)
+		l.last = now
+	}
+}
+
+// duration returns the time needed to accumulate the given amount of tokens.
+func (l *Limiter) duration(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	limiter := NewLimiter(1, 2)
+	utils.Equal(t, limiter.Allow(), true)
+	utils.Equal(t, limiter.Allow(), true)
+	utils.Equal(t, limiter.Allow(), false)
+
+	delay := limiter.Delay()
+	utils.Equal(t, delay > 900*time.Millisecond, true)
+	utils.Equal(t, delay <= time.Second, true)
+}
+
+func TestLimiterWait(t *testing.T) {
+	limiter := NewLimiter(100, 1)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		utils.Equal(t, limiter.Wait(context.Background()), nil)
+	}
+	utils.Equal(t, time.Since(start) >= 15*time.Millisecond, true)
+}
+
+func TestLimiterWaitCancel(t *testing.T) {
+	limiter := NewLimiter(0.1, 1)
+	utils.Equal(t, limiter.Allow(), true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	utils.Equal(t, limiter.Wait(ctx), context.DeadlineExceeded)
+	utils.Equal(t, limiter.Tokens() < 1, true)
+	utils.Equal(t, limiter.Tokens() >= 0, true)
+}
+
+func TestLimiterInvalidRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		func() {
+			defer func() {
+				utils.Equal(t, recover(), "ratelimit: rate must be positive, got "+strconv.FormatFloat(rate, 'g', -1, 64))
+			}()
+			NewLimiter(rate, 1)
+		}()
+	}
+
+	defer func() {
+		utils.NotEqual(t, recover(), nil)
+	}()
+	New(Options{Burst: 1})
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/mux"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// ErrLimited is wrapped by LimitError when a request exceeds the rate limit.
+var ErrLimited = errors.New("ratelimit: rate limit exceeded")
+
+// LimitError is reported in fail fast mode when a request exceeds the rate limit.
+type LimitError struct {
+	// Key stores the rate limit key of the request.
+	Key string
+
+	// RetryAfter stores the time until the next request would be allowed.
+	RetryAfter time.Duration
+
+	// Err stores the original error of the failed response, if any.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	msg := fmt.Sprintf("%s for %s, retry after %s", ErrLimited, e.Key, e.RetryAfter)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Delay returns the time to wait before retrying.
+// It implements the retrier.Delayer interface.
+func (e *LimitError) Delay() time.Duration {
+	return e.RetryAfter
+}
+
+// Unwrap returns ErrLimited and the original error, if any.
+func (e *LimitError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrLimited, e.Err}
+	}
+	return []error{ErrLimited}
+}
+
+// KeyFunc returns the rate limit key for the given HTTP transaction context.
+// Requests with the same key share the same token bucket.
+// An empty key means the request is not rate limited.
+type KeyFunc func(ctx *c.Context) string
+
+// Host keys requests by the target URL host.
+func Host(ctx *c.Context) string {
+	return ctx.Request.URL.Host
+}
+
+// Route keys requests matching the given multiplexer with the given name.
+// Requests not matching the multiplexer are not rate limited.
+func Route(name string, m *mux.Mux) KeyFunc {
+	return func(ctx *c.Context) string {
+		// The rate limit is applied before dialing, once the outgoing request is fully built
+		if mux.MatchRequest(ctx, m.Match) {
+			return name
+		}
+		return ""
+	}
+}
+
+// Options represents the rate limit plugin options.
+type Options struct {
+	// Rate defines the allowed requests per second per key.
+	Rate float64
+
+	// Burst defines the maximum number of requests allowed at once per key.
+	// Defaults to 1.
+	Burst int
+
+	// Key defines the function used to group requests in buckets.
+	// Defaults to Host.
+	Key KeyFunc
+
+	// FailFast reports a LimitError instead of waiting
+	// for the request to be allowed.
+	FailFast bool
+}
+
+// RateLimiter stores the token buckets by key.
+// It implements the plugin interface, so the limiter state is shared across
+// all the requests dispatched by a Client and its children via UseParent.
+type RateLimiter struct {
+	p.Plugin
+
+	// opts stores the rate limiter options
+	opts Options
+
+	// mtx protects limiters
+	mtx sync.Mutex
+
+	// limiters stores the token buckets by key
+	limiters map[string]*Limiter
+}
+
+// New creates a new rate limiter plugin with the given options.
+// By default, requests wait until allowed, aborting if the request
+// context is canceled or its deadline is exceeded.
+// Panics if the rate is not positive.
+func New(opts Options) *RateLimiter {
+	if opts.Rate <= 0 {
+		panic(fmt.Sprintf("ratelimit: rate must be positive, got %v", opts.Rate))
+	}
+	if opts.Key == nil {
+		opts.Key = Host
+	}
+
+	rl := &RateLimiter{opts: opts, limiters: map[string]*Limiter{}}
+	rl.Plugin = p.NewPhasePlugin("before dial", rl.limit)
+	return rl
+}
+
+// Limiter returns the token bucket for the given key, creating it if needed.
+func (rl *RateLimiter) Limiter(key string) *Limiter {
+	rl.mtx.Lock()
+	defer rl.mtx.Unlock()
+
+	limiter, ok := rl.limiters[key]
+	if !ok {
+		limiter = NewLimiter(rl.opts.Rate, rl.opts.Burst)
+		rl.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// limit waits or fails until the outgoing request is allowed.
+func (rl *RateLimiter) limit(ctx *c.Context, h c.Handler) {
+	key := rl.opts.Key(ctx)
+	if key == "" {
+		h.Next(ctx)
+		return
+	}
+
+	limiter := rl.Limiter(key)
+	if rl.opts.FailFast {
+		if delay := limiter.Delay(); delay > 0 {
+			h.Error(ctx, &LimitError{Key: key, RetryAfter: delay})
+			return
+		}
+		h.Next(ctx)
+		return
+	}
+
+	if err := limiter.Wait(ctx); err != nil {
+		h.Error(ctx, err)
+		return
+	}
+	h.Next(ctx)
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman"
+	"github.com/lytics/gentleman/mux"
+	"github.com/lytics/gentleman/utils"
+)
+
+func newServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+}
+
+func TestRateLimitFailFast(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Rate: 1, Burst: 1, FailFast: true}))
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+
+	_, err = cli.Request().Send()
+	utils.Equal(t, errors.Is(err, ErrLimited), true)
+
+	var limitErr *LimitError
+	utils.Equal(t, errors.As(err, &limitErr), true)
+	utils.Equal(t, limitErr.Key, ts.Listener.Addr().String())
+	utils.Equal(t, limitErr.RetryAfter > 0, true)
+}
+
+func TestRateLimitWait(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Rate: 50, Burst: 1}))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := cli.Request().Send()
+		utils.Equal(t, err, nil)
+	}
+	utils.Equal(t, time.Since(start) >= 35*time.Millisecond, true)
+}
+
+func TestRateLimitWaitCancel(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Rate: 0.1, Burst: 1}))
+
+	_, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = cli.Request().DoContext(ctx)
+	utils.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+}
+
+func TestRateLimitRoute(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Rate: 1, FailFast: true, Key: Route("users", mux.Path("/users"))}))
+
+	_, err := cli.Request().Path("/users").Send()
+	utils.Equal(t, err, nil)
+	_, err = cli.Request().Path("/users").Send()
+	utils.NotEqual(t, err, nil)
+
+	// Non matching requests are not limited
+	_, err = cli.Request().Path("/groups").Send()
+	utils.Equal(t, err, nil)
+	_, err = cli.Request().Path("/groups").Send()
+	utils.Equal(t, err, nil)
+}
+
+func TestRateLimitSharedParent(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+
+	parent := gentleman.New()
+	rl := New(Options{Rate: 1, FailFast: true})
+	parent.Use(rl)
+
+	child := gentleman.New().URL(ts.URL).UseParent(parent)
+	sibling := gentleman.New().URL(ts.URL).UseParent(parent)
+
+	_, err := child.Request().Send()
+	utils.Equal(t, err, nil)
+	_, err = sibling.Request().Send()
+	utils.Equal(t, errors.Is(err, ErrLimited), true)
+	utils.Equal(t, rl.Limiter(ts.Listener.Addr().String()).Tokens() < 1, true)
+}
@@ -0,0 +1,147 @@
+package recorder
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// Base64 defines the body encoding of binary payloads.
+const Base64 = "base64"
+
+// Cassette represents a set of recorded HTTP interactions stored in a file.
+// Cassettes are stored as indented JSON, regardless of the file extension.
+// Since JSON is valid YAML, files with a .yaml or .yml extension
+// can be read by YAML tools as well, but only JSON documents can be loaded.
+type Cassette struct {
+	// Path stores the cassette file path.
+	Path string `json:"-"`
+
+	// Interactions stores the recorded interactions.
+	Interactions []*Interaction `json:"interactions"`
+
+	// mtx protects the interactions and replayed
+	mtx sync.Mutex
+
+	// replayed stores whether each interaction has been replayed
+	replayed []bool
+}
+
+// Interaction represents a recorded HTTP request and response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request represents a recorded HTTP request.
+type Request struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// Response represents a recorded HTTP response.
+type Response struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// Load loads the cassette stored in the given path.
+func Load(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := &Cassette{Path: path}
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, err
+	}
+	cassette.replayed = make([]bool, len(cassette.Interactions))
+	return cassette, nil
+}
+
+// Save writes the cassette into its file, creating the parent directories if needed.
+func (c *Cassette) Save() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(c.Path), ".cassette-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.Path)
+}
+
+// Add appends the given interaction.
+func (c *Cassette) Add(i *Interaction) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.Interactions = append(c.Interactions, i)
+	c.replayed = append(c.replayed, true)
+}
+
+// Find returns the first interaction matching the given request
+// not replayed yet, or the last matching one if all have been replayed.
+func (c *Cassette) Find(req *Request, match Matcher) *Interaction {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	last := -1
+	for i, interaction := range c.Interactions {
+		if !match(req, &interaction.Request) {
+			continue
+		}
+		if !c.replayed[i] {
+			c.replayed[i] = true
+			return interaction
+		}
+		last = i
+	}
+	if last < 0 {
+		return nil
+	}
+	return c.Interactions[last]
+}
+
+// encodeBody encodes the given body, using base64 for binary payloads.
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), Base64
+}
+
+// decodeBody decodes the given body based on its encoding.
+func decodeBody(body, encoding string) ([]byte, error) {
+	if encoding == Base64 {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,264 @@
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// Redacted defines the value replacing the redacted header values.
+const Redacted = "[REDACTED]"
+
+// DefaultRedact stores the headers redacted by default.
+var DefaultRedact = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// ErrNotFound is returned in Replay mode when no interaction matches the request.
+var ErrNotFound = errors.New("recorder: no matching interaction")
+
+// Mode represents the recorder mode.
+type Mode int
+
+const (
+	// Replay replays the recorded interactions, failing if there is no match.
+	// The network is never used.
+	Replay Mode = iota
+
+	// Record sends the requests, recording the interactions into a new cassette.
+	Record
+
+	// RecordMissing replays the recorded interactions, sending
+	// and recording the requests with no matching interaction.
+	RecordMissing
+
+	// Passthrough sends the requests without recording nor replaying.
+	Passthrough
+)
+
+// Matcher reports whether the given request matches a recorded one.
+type Matcher func(req, recorded *Request) bool
+
+// Method matches the request method.
+func Method(req, recorded *Request) bool {
+	return req.Method == recorded.Method
+}
+
+// URL matches the request URL.
+func URL(req, recorded *Request) bool {
+	return req.URL == recorded.URL
+}
+
+// Body matches the request body.
+func Body(req, recorded *Request) bool {
+	return req.Body == recorded.Body && req.BodyEncoding == recorded.BodyEncoding
+}
+
+// Headers returns a matcher matching the values of the given headers.
+func Headers(names ...string) Matcher {
+	return func(req, recorded *Request) bool {
+		for _, name := range names {
+			if strings.Join(req.Header.Values(name), ",") != strings.Join(recorded.Header.Values(name), ",") {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Match returns a matcher matching all the given matchers.
+func Match(matchers ...Matcher) Matcher {
+	return func(req, recorded *Request) bool {
+		for _, match := range matchers {
+			if !match(req, recorded) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Options represents the recorder options.
+type Options struct {
+	// Mode defines the recorder mode.
+	Mode Mode
+
+	// Matcher defines the request matcher. Defaults to the method and URL.
+	Matcher Matcher
+
+	// Redact defines the headers whose values are replaced before writing.
+	// Defaults to DefaultRedact.
+	Redact []string
+
+	// Filter optionally modifies the interactions before writing,
+	// such as to redact secrets in the URL or bodies.
+	// Requests are also filtered before matching.
+	Filter func(*Interaction)
+}
+
+// Recorder records and replays HTTP interactions using a cassette file.
+// Interactions are written into the cassette as they are recorded.
+type Recorder struct {
+	p.Plugin
+
+	// opts stores the recorder options
+	opts Options
+
+	// cassette stores the recorded interactions
+	cassette *Cassette
+}
+
+// New creates a new recorder plugin using the cassette in the given path.
+// The cassette must exist in Replay mode, and it is overwritten in Record mode.
+func New(path string, opts Options) (*Recorder, error) {
+	if opts.Matcher == nil {
+		opts.Matcher = Match(Method, URL)
+	}
+	if opts.Redact == nil {
+		opts.Redact = DefaultRedact
+	}
+
+	cassette := &Cassette{Path: path}
+	if opts.Mode == Replay || opts.Mode == RecordMissing {
+		loaded, err := Load(path)
+		if err != nil && !(opts.Mode == RecordMissing && os.IsNotExist(err)) {
+			return nil, err
+		}
+		if loaded != nil {
+			cassette = loaded
+		}
+	}
+
+	r := &Recorder{opts: opts, cassette: cassette}
+	r.Plugin = p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		if r.opts.Mode != Passthrough {
+			ctx.Client.Transport = &Transport{r, ctx.Client.Transport, ctx}
+		}
+		h.Next(ctx)
+	})
+	return r, nil
+}
+
+// Cassette returns the recorder cassette.
+func (r *Recorder) Cassette() *Cassette {
+	return r.cassette
+}
+
+// Transport provides a http.RoundTripper compatible transport who encapsulates
+// the original http.Transport and records or replays the interactions.
+type Transport struct {
+	recorder  *Recorder
+	transport http.RoundTripper
+	context   *c.Context
+}
+
+// RoundTrip implements the required method by http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Restore original http.Transport
+	t.context.Client.Transport = t.transport
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	r := t.recorder
+	if r.opts.Mode != Record {
+		live := &Interaction{Request: newRequest(req, body)}
+		r.filter(live)
+		if recorded := r.cassette.Find(&live.Request, r.opts.Matcher); recorded != nil {
+			return recorded.Response.response(req)
+		}
+		if r.opts.Mode == Replay {
+			return nil, fmt.Errorf("%w: %s %s", ErrNotFound, req.Method, live.Request.URL)
+		}
+	}
+
+	res, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	interaction := &Interaction{Request: newRequest(req, body), Response: newResponse(res, resBody)}
+	r.filter(interaction)
+	r.cassette.Add(interaction)
+
+	// Fail the request rather than silently losing the recorded interaction
+	if err := r.cassette.Save(); err != nil {
+		res.Body.Close()
+		return nil, fmt.Errorf("recorder: cannot save cassette: %w", err)
+	}
+	return res, nil
+}
+
+// filter redacts the headers and applies the custom filter to the given interaction.
+func (r *Recorder) filter(i *Interaction) {
+	for _, name := range r.opts.Redact {
+		for _, header := range []http.Header{i.Request.Header, i.Response.Header} {
+			if header.Get(name) != "" {
+				header.Set(name, Redacted)
+			}
+		}
+	}
+	if r.opts.Filter != nil {
+		r.opts.Filter(i)
+	}
+}
+
+// newRequest creates a recorded request from the given request.
+func newRequest(req *http.Request, body []byte) Request {
+	recorded := Request{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone()}
+	recorded.Body, recorded.BodyEncoding = encodeBody(body)
+	return recorded
+}
+
+// newResponse creates a recorded response from the given response.
+func newResponse(res *http.Response, body []byte) Response {
+	recorded := Response{StatusCode: res.StatusCode, Header: res.Header.Clone()}
+	recorded.Body, recorded.BodyEncoding = encodeBody(body)
+	return recorded
+}
+
+// response creates a new http.Response replaying the recorded response.
+func (r *Response) response(req *http.Request) (*http.Response, error) {
+	body, err := decodeBody(r.Body, r.BodyEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	return &http.Response{
+		Status:        strconv.Itoa(r.StatusCode) + " " + http.StatusText(r.StatusCode),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,190 @@
+package recorder
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lytics/gentleman"
+	"github.com/lytics/gentleman/utils"
+)
+
+func newServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Path", r.URL.Path)
+		w.WriteHeader(201)
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(body)))
+	}))
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "recorder")
+	utils.Equal(t, err, nil)
+	return dir
+}
+
+func TestRecordReplay(t *testing.T) {
+	for _, name := range []string{"cassette.json", "cassette.yaml"} {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "fixtures", name)
+
+		var hits int32
+		ts := newServer(&hits)
+		url := ts.URL
+
+		rec, err := New(path, Options{Mode: Record})
+		utils.Equal(t, err, nil)
+		cli := gentleman.New().URL(url).Use(rec)
+		cli.Request().Path("/foo").SetHeader("Authorization", "Bearer secret").Send()
+		res, err := cli.Request().Method("POST").Path("/bar").BodyString("hello\nworld").Send()
+		utils.Equal(t, err, nil)
+		utils.Equal(t, res.StatusCode, 201)
+		utils.Equal(t, res.String(), "POST /bar hello\nworld")
+		utils.Equal(t, atomic.LoadInt32(&hits), int32(2))
+		ts.Close()
+
+		data, _ := ioutil.ReadFile(path)
+		utils.Equal(t, strings.Contains(string(data), "secret"), false)
+		utils.Equal(t, strings.Contains(string(data), Redacted), true)
+
+		rec, err = New(path, Options{Mode: Replay})
+		utils.Equal(t, err, nil)
+		utils.Equal(t, len(rec.Cassette().Interactions), 2)
+		cli = gentleman.New().URL(url).Use(rec)
+
+		res, err = cli.Request().Method("POST").Path("/bar").BodyString("other").Send()
+		utils.Equal(t, err, nil)
+		utils.Equal(t, res.StatusCode, 201)
+		utils.Equal(t, res.String(), "POST /bar hello\nworld")
+		utils.Equal(t, res.Header.Get("X-Path"), "/bar")
+		utils.Equal(t, res.Header.Get("Set-Cookie"), Redacted)
+
+		_, err = cli.Request().Path("/missing").Send()
+		utils.Equal(t, errors.Is(err, ErrNotFound), true)
+	}
+}
+
+func TestRecordMissing(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.yml")
+
+	var hits int32
+	ts := newServer(&hits)
+	defer ts.Close()
+
+	_, err := New(path, Options{Mode: Replay})
+	utils.NotEqual(t, err, nil)
+
+	rec, err := New(path, Options{Mode: RecordMissing, Matcher: Match(Method, URL, Body, Headers("X-Version"))})
+	utils.Equal(t, err, nil)
+	cli := gentleman.New().URL(ts.URL).Use(rec)
+
+	send := func(body, version string) string {
+		res, err := cli.Request().Method("POST").BodyString(body).SetHeader("X-Version", version).Send()
+		utils.Equal(t, err, nil)
+		return res.String()
+	}
+	utils.Equal(t, send("a", "1"), "POST / a")
+	utils.Equal(t, send("a", "1"), "POST / a")
+	utils.Equal(t, send("b", "1"), "POST / b")
+	utils.Equal(t, send("a", "2"), "POST / a")
+	utils.Equal(t, atomic.LoadInt32(&hits), int32(3))
+
+	cassette, err := Load(path)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, len(cassette.Interactions), 3)
+	utils.Equal(t, cassette.Interactions[2].Request.Header.Get("X-Version"), "2")
+}
+
+func TestRecordSaveError(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// The cassette directory cannot be created under a regular file
+	blocker := filepath.Join(dir, "blocker")
+	utils.Equal(t, ioutil.WriteFile(blocker, nil, 0644), nil)
+
+	var hits int32
+	ts := newServer(&hits)
+	defer ts.Close()
+
+	rec, err := New(filepath.Join(blocker, "cassette.json"), Options{Mode: Record})
+	utils.Equal(t, err, nil)
+	res, err := gentleman.New().URL(ts.URL).Use(rec).Request().Send()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, strings.Contains(err.Error(), "recorder: cannot save cassette: "), true)
+	utils.Equal(t, res.StatusCode, 0)
+	utils.Equal(t, atomic.LoadInt32(&hits), int32(1))
+}
+
+func TestReplayOrder(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	cassette := &Cassette{Path: path}
+	for _, body := range []string{"first", "second"} {
+		cassette.Add(&Interaction{
+			Request:  Request{Method: "GET", URL: "http://example.com/poll"},
+			Response: Response{StatusCode: 200, Body: body},
+		})
+	}
+	cassette.Add(&Interaction{
+		Request:  Request{Method: "GET", URL: "http://example.com/binary"},
+		Response: Response{StatusCode: 200, Body: "AP8=", BodyEncoding: Base64},
+	})
+	utils.Equal(t, cassette.Save(), nil)
+
+	rec, err := New(path, Options{Mode: Replay})
+	utils.Equal(t, err, nil)
+	cli := gentleman.New().URL("http://example.com").Use(rec)
+
+	for _, expected := range []string{"first", "second", "second"} {
+		res, err := cli.Request().Path("/poll").Send()
+		utils.Equal(t, err, nil)
+		utils.Equal(t, res.String(), expected)
+	}
+
+	res, err := cli.Request().Path("/binary").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.Bytes(), []byte{0, 255})
+}
+
+func TestFilterPassthrough(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	var hits int32
+	ts := newServer(&hits)
+	defer ts.Close()
+
+	filter := func(i *Interaction) {
+		i.Request.URL = strings.Replace(i.Request.URL, "token=secret", "token=xxx", 1)
+	}
+	rec, _ := New(path, Options{Mode: Record, Filter: filter})
+	gentleman.New().URL(ts.URL).Use(rec).Request().AddQuery("token", "secret").Send()
+	utils.Equal(t, strings.HasSuffix(rec.Cassette().Interactions[0].Request.URL, "token=xxx"), true)
+
+	rec, _ = New(path, Options{Mode: Replay, Filter: filter})
+	res, err := gentleman.New().URL(ts.URL).Use(rec).Request().AddQuery("token", "secret").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 201)
+	utils.Equal(t, atomic.LoadInt32(&hits), int32(1))
+
+	rec, _ = New(path, Options{Mode: Passthrough})
+	gentleman.New().URL(ts.URL).Use(rec).Request().Send()
+	utils.Equal(t, atomic.LoadInt32(&hits), int32(2))
+	utils.Equal(t, len(rec.Cassette().Interactions), 0)
+}
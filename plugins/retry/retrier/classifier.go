@@ -1,5 +1,7 @@
 package retrier
 
+import "errors"
+
 // Action is the type returned by a Classifier to indicate how the Retrier should proceed.
 type Action int
 
@@ -32,6 +34,7 @@ func (c DefaultClassifier) Classify(err error) Action {
 
 // WhitelistClassifier classifies errors based on a whitelist. If the error is nil, it
 // returns Succeed; if the error is in the whitelist, it returns Retry; otherwise, it returns Fail.
+// Wrapped errors are matched via errors.Is.
 type WhitelistClassifier []error
 
 // Classify implements the Classifier interface.
@@ -41,7 +44,7 @@ func (list WhitelistClassifier) Classify(err error) Action {
 	}
 
 	for _, pass := range list {
-		if err == pass {
+		if errors.Is(err, pass) {
 			return Retry
 		}
 	}
@@ -51,6 +54,7 @@ func (list WhitelistClassifier) Classify(err error) Action {
 
 // BlacklistClassifier classifies errors based on a blacklist. If the error is nil, it
 // returns Succeed; if the error is in the blacklist, it returns Fail; otherwise, it returns Retry.
+// Wrapped errors are matched via errors.Is.
 type BlacklistClassifier []error
 
 // Classify implements the Classifier interface.
@@ -60,7 +64,7 @@ func (list BlacklistClassifier) Classify(err error) Action {
 	}
 
 	for _, pass := range list {
-		if err == pass {
+		if errors.Is(err, pass) {
 			return Fail
 		}
 	}
@@ -2,6 +2,7 @@ package retrier
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -45,6 +46,9 @@ func TestWhitelistClassifier(t *testing.T) {
 	if c.Classify(errBaz) != Fail {
 		t.Error("whitelist misclassified baz")
 	}
+	if c.Classify(fmt.Errorf("wrapped: %w", errFoo)) != Retry {
+		t.Error("whitelist misclassified wrapped foo")
+	}
 }
 
 func TestBlacklistClassifier(t *testing.T) {
@@ -63,4 +67,7 @@ func TestBlacklistClassifier(t *testing.T) {
 	if c.Classify(errBaz) != Retry {
 		t.Error("blacklist misclassified baz")
 	}
+	if c.Classify(fmt.Errorf("wrapped: %w", errBar)) != Fail {
+		t.Error("blacklist misclassified wrapped bar")
+	}
 }
@@ -2,6 +2,8 @@
 package retrier
 
 import (
+	"context"
+	"errors"
 	"math/rand"
 	"time"
 )
@@ -15,6 +17,38 @@ type Retrier struct {
 	rand    *rand.Rand
 }
 
+// Delayer is implemented by errors defining the exact amount of time to wait
+// before the next retry, such as server responses with a Retry-After header.
+// When the work function returns a Delayer error, its delay replaces the back-off.
+type Delayer interface {
+	Delay() time.Duration
+}
+
+// Permanent wraps the given error to make the retrier fail fast regardless of the classifier,
+// such as when the delay requested by the server exceeds the maximum allowed wait.
+// The wrapped error is available via errors.Unwrap, errors.Is and errors.As.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// permanentError represents an error which must not be retried.
+type permanentError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
 // New constructs a Retrier with the given backoff pattern and classifier. The length of the backoff pattern
 // indicates how many times an action will be retried, and the value at each index indicates the amount of time
 // waited before each subsequent retry. The classifier is used to determine which errors should be retried and
@@ -37,10 +71,21 @@ func New(backoff []time.Duration, class Classifier) *Retrier {
 // before retrying. If the total number of retries is exceeded then the return value of the work function
 // is returned to the caller regardless.
 func (r *Retrier) Run(work func() error) error {
+	return r.RunContext(context.Background(), work)
+}
+
+// RunContext executes the given work function like Run, but stops retrying as soon as the given context
+// is done, returning the context error to the caller. Back-off sleeps are interrupted by the context.
+func (r *Retrier) RunContext(ctx context.Context, work func() error) error {
 	retries := 0
 	for {
 		ret := work()
 
+		var permanent *permanentError
+		if errors.As(ret, &permanent) {
+			return ret
+		}
+
 		switch r.class.Classify(ret) {
 		case Succeed, Fail:
 			return ret
@@ -48,12 +93,31 @@ func (r *Retrier) Run(work func() error) error {
 			if retries >= len(r.backoff) {
 				return ret
 			}
-			time.Sleep(r.calcSleep(retries))
+
+			timer := time.NewTimer(r.delay(ret, retries))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
 			retries++
 		}
 	}
 }
 
+// delay returns the time to wait before the given retry,
+// honoring the delay defined by Delayer errors.
+func (r *Retrier) delay(err error, i int) time.Duration {
+	var delayer Delayer
+	if errors.As(err, &delayer) {
+		if delay := delayer.Delay(); delay >= 0 {
+			return delay
+		}
+	}
+	return r.calcSleep(i)
+}
+
 func (r *Retrier) calcSleep(i int) time.Duration {
 	// take a random float in the range (-r.jitter, +r.jitter) and multiply it by the base amount
 	return r.backoff[i] + time.Duration(((r.rand.Float64()*2)-1)*r.jitter*float64(r.backoff[i]))
@@ -1,6 +1,8 @@
 package retrier
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -115,6 +117,26 @@ func TestRetrierJitter(t *testing.T) {
 	}
 }
 
+func TestRetrierRunContext(t *testing.T) {
+	r := New([]time.Duration{0, time.Hour}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	i := 0
+	err := r.RunContext(ctx, func() error {
+		i++
+		if i == 2 {
+			cancel()
+		}
+		return errFoo
+	})
+	if err != context.Canceled {
+		t.Error("expected context error, got", err)
+	}
+	if i != 2 {
+		t.Error("run wrong number of times")
+	}
+}
+
 func ExampleRetrier() {
 	r := New(ConstantBackoff(3, 100*time.Millisecond), nil)
 
@@ -127,3 +149,39 @@ func ExampleRetrier() {
 		// handle the case where the work failed three times
 	}
 }
+
+type delayError time.Duration
+
+func (e delayError) Error() string        { return "delay" }
+func (e delayError) Delay() time.Duration { return time.Duration(e) }
+
+func TestRetrierDelayer(t *testing.T) {
+	r := New([]time.Duration{time.Minute}, nil)
+
+	start := time.Now()
+	err := r.Run(genWork([]error{delayError(10 * time.Millisecond)}))
+	if err != nil {
+		t.Error(err)
+	}
+	if i != 2 {
+		t.Error("run wrong number of times")
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond || elapsed > time.Second {
+		t.Error("delay not honored:", elapsed)
+	}
+}
+
+func TestRetrierPermanent(t *testing.T) {
+	r := New([]time.Duration{0, 0}, nil)
+
+	err := r.Run(genWork([]error{Permanent(errFoo), errFoo}))
+	if !errors.Is(err, errFoo) {
+		t.Error(err)
+	}
+	if i != 1 {
+		t.Error("run wrong number of times")
+	}
+	if Permanent(nil) != nil {
+		t.Error("permanent nil error")
+	}
+}
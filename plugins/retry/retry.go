@@ -2,6 +2,7 @@ package retry
 
 import (
 	"bytes"
+	gocontext "context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -38,6 +39,12 @@ type Retrier interface {
 	Run(func() error) error
 }
 
+// ContextRetrier defines the optional interface implemented by retry strategies
+// able to abort the retry back-off when the request context.Context is canceled.
+type ContextRetrier interface {
+	RunContext(gocontext.Context, func() error) error
+}
+
 // EvalFunc represents the function interface for failed request evaluator.
 type EvalFunc func(error, *http.Response, *http.Request) error
 
@@ -109,7 +116,7 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.Body.Close()
 
 	// Transport request via retrier
-	t.retrier.Run(func() error {
+	rerr := t.run(req.Context(), func() error {
 		// Clone the http.Request for side effects free
 		reqCopy := &http.Request{}
 		*reqCopy = *req
@@ -125,5 +132,22 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	// Restore original http.Transport
 	t.context.Client.Transport = t.transport
 
+	// Discard the last response if the request context was canceled while retrying
+	if rerr != nil && req.Context().Err() != nil {
+		if res != nil && res.Body != nil {
+			res.Body.Close()
+		}
+		return nil, req.Context().Err()
+	}
+
 	return res, err
 }
+
+// run runs the given work function via the retrier,
+// using the request context if the retrier supports it.
+func (t *Transport) run(ctx gocontext.Context, work func() error) error {
+	if retrier, ok := t.retrier.(ContextRetrier); ok {
+		return retrier.RunContext(ctx, work)
+	}
+	return t.retrier.Run(work)
+}
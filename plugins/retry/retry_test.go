@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -120,6 +121,30 @@ func TestRetryNetworkTimeout(t *testing.T) {
 	utils.Equal(t, res.StatusCode, 0)
 }
 
+func TestRetryContextCancel(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	req := gentleman.NewRequest()
+	req.URL(ts.URL)
+	req.Use(New(nil, nil))
+
+	start := time.Now()
+	res, err := req.DoContext(ctx)
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	utils.Equal(t, res.StatusCode, 0)
+	utils.Equal(t, calls, 1)
+	utils.Equal(t, time.Since(start) < RetryWait, true)
+}
+
 func TestCustomEvaluator(t *testing.T) {
 	calls := 0
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
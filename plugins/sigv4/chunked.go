@@ -0,0 +1,109 @@
+package sigv4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChunkSize defines the payload chunk size used by the Streaming mode.
+var ChunkSize = 64 * 1024
+
+// signatureLength defines the hex-encoded signature length.
+const signatureLength = 64
+
+// chunkedReader encodes and signs the payload chunks using the aws-chunked encoding.
+type chunkedReader struct {
+	body      io.ReadCloser
+	key       []byte
+	date      string
+	scope     string
+	signature string
+	buf       bytes.Buffer
+	chunk     []byte
+	done      bool
+}
+
+// newChunkedReader creates a new chunked reader based on the given
+// signing key, scope and seed signature.
+func newChunkedReader(body io.ReadCloser, key []byte, t time.Time, scope, seed string) *chunkedReader {
+	if body == nil {
+		body = ioutil.NopCloser(strings.NewReader(""))
+	}
+	return &chunkedReader{
+		body:      body,
+		key:       key,
+		date:      t.Format(timeFormat),
+		scope:     scope,
+		signature: seed,
+		chunk:     make([]byte, ChunkSize),
+	}
+}
+
+// Read implements the io.Reader interface.
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		n, err := io.ReadFull(r.body, r.chunk)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = nil
+		} else if err != nil {
+			return 0, err
+		}
+
+		r.writeChunk(r.chunk[:n])
+		if n == 0 {
+			r.done = true
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// Close implements the io.Closer interface.
+func (r *chunkedReader) Close() error {
+	return r.body.Close()
+}
+
+// writeChunk signs and writes the given chunk into the buffer.
+func (r *chunkedReader) writeChunk(data []byte) {
+	toSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256-PAYLOAD",
+		r.date,
+		r.scope,
+		r.signature,
+		emptyHash,
+		hashHex(data),
+	}, "\n")
+	r.signature = hex.EncodeToString(hmacSHA256(r.key, toSign))
+
+	r.buf.WriteString(strconv.FormatInt(int64(len(data)), 16))
+	r.buf.WriteString(";chunk-signature=")
+	r.buf.WriteString(r.signature)
+	r.buf.WriteString("\r\n")
+	r.buf.Write(data)
+	r.buf.WriteString("\r\n")
+}
+
+// chunkedLength returns the encoded length of a payload of the given length.
+func chunkedLength(length int64) int64 {
+	size := int64(ChunkSize)
+	full := length / size
+	total := full * chunkLength(size)
+	if rest := length % size; rest > 0 {
+		total += chunkLength(rest)
+	}
+	return total + chunkLength(0)
+}
+
+// chunkLength returns the encoded length of a chunk of the given size.
+func chunkLength(size int64) int64 {
+	header := len(strconv.FormatInt(size, 16)) + len(";chunk-signature=") + signatureLength + 2
+	return int64(header) + size + 2
+}
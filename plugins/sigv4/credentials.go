@@ -0,0 +1,159 @@
+package sigv4
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoCredentials is returned when a provider cannot find credentials.
+var ErrNoCredentials = errors.New("sigv4: no credentials found")
+
+// Credentials represents AWS-style access credentials.
+type Credentials struct {
+	// AccessKeyID stores the access key identifier.
+	AccessKeyID string
+
+	// SecretAccessKey stores the secret key used to sign requests.
+	SecretAccessKey string
+
+	// SessionToken stores the optional temporary session token.
+	SessionToken string
+}
+
+// Provider represents the interface implemented by credential providers.
+type Provider interface {
+	// Retrieve returns the credentials used to sign a request.
+	Retrieve() (Credentials, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface.
+type ProviderFunc func() (Credentials, error)
+
+// Retrieve implements the Provider interface.
+func (fn ProviderFunc) Retrieve() (Credentials, error) {
+	return fn()
+}
+
+// Static returns a provider of the given fixed credentials.
+func Static(accessKeyID, secretAccessKey, sessionToken string) Provider {
+	return ProviderFunc(func() (Credentials, error) {
+		return Credentials{accessKeyID, secretAccessKey, sessionToken}, nil
+	})
+}
+
+// Env returns a provider reading the credentials from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+func Env() Provider {
+	return ProviderFunc(func() (Credentials, error) {
+		creds := Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyID == "" {
+			creds.AccessKeyID = os.Getenv("AWS_ACCESS_KEY")
+		}
+		if creds.SecretAccessKey == "" {
+			creds.SecretAccessKey = os.Getenv("AWS_SECRET_KEY")
+		}
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return Credentials{}, fmt.Errorf("%w in environment", ErrNoCredentials)
+		}
+		return creds, nil
+	})
+}
+
+// File returns a provider reading the credentials of the given profile
+// from a shared credentials file in INI format.
+// If path is empty, AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials is used.
+// If profile is empty, AWS_PROFILE or "default" is used.
+func File(path, profile string) Provider {
+	return ProviderFunc(func() (Credentials, error) {
+		file, name := path, profile
+		if file == "" {
+			file = os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+		}
+		if file == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return Credentials{}, err
+			}
+			file = filepath.Join(home, ".aws", "credentials")
+		}
+		if name == "" {
+			name = os.Getenv("AWS_PROFILE")
+		}
+		if name == "" {
+			name = "default"
+		}
+		return readFile(file, name)
+	})
+}
+
+// Chain returns a provider trying the given providers in order,
+// returning the first credentials found.
+func Chain(providers ...Provider) Provider {
+	return ProviderFunc(func() (Credentials, error) {
+		errs := []string{}
+		for _, provider := range providers {
+			creds, err := provider.Retrieve()
+			if err == nil {
+				return creds, nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return Credentials{}, fmt.Errorf("%w: %s", ErrNoCredentials, strings.Join(errs, "; "))
+	})
+}
+
+// readFile reads the credentials of the given profile from a shared credentials file.
+func readFile(path, profile string) (Credentials, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer file.Close()
+
+	creds := Credentials{}
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != profile {
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i < 0 {
+			continue
+		}
+		value := strings.TrimSpace(line[i+1:])
+		switch strings.ToLower(strings.TrimSpace(line[:i])) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = value
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = value
+		case "aws_session_token":
+			creds.SessionToken = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Credentials{}, err
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("%w for profile %s in %s", ErrNoCredentials, profile, path)
+	}
+	return creds, nil
+}
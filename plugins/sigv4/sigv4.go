@@ -0,0 +1,351 @@
+package sigv4
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+const (
+	// Algorithm defines the signing algorithm identifier.
+	Algorithm = "AWS4-HMAC-SHA256"
+
+	// UnsignedPayload defines the payload hash value of unsigned payloads.
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// StreamingPayload defines the payload hash value of chunked signed payloads.
+	StreamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+
+	// MaxExpires defines the maximum validity of presigned URLs.
+	MaxExpires = 7 * 24 * time.Hour
+)
+
+const (
+	timeFormat  = "20060102T150405Z"
+	shortFormat = "20060102"
+)
+
+// emptyHash stores the SHA256 hash of an empty payload.
+var emptyHash = hashHex(nil)
+
+// ignoredHeaders stores the headers excluded from the signature,
+// since they may be modified by proxies or by the HTTP transport.
+var ignoredHeaders = map[string]bool{
+	"authorization":     true,
+	"user-agent":        true,
+	"x-amzn-trace-id":   true,
+	"expect":            true,
+	"connection":        true,
+	"transfer-encoding": true,
+	"accept-encoding":   true,
+}
+
+// Payload represents the payload signing mode.
+type Payload int
+
+const (
+	// Signed hashes the whole payload, buffering it in memory.
+	Signed Payload = iota
+
+	// Unsigned excludes the payload from the signature.
+	Unsigned
+
+	// Streaming signs the payload in chunks while it is sent,
+	// using the aws-chunked content encoding.
+	// The request content length must be known.
+	Streaming
+)
+
+// Signer signs HTTP requests using the AWS Signature Version 4 algorithm.
+type Signer struct {
+	// Credentials defines the credentials provider.
+	Credentials Provider
+
+	// Region defines the signing region, such as us-east-1.
+	Region string
+
+	// Service defines the signing service name, such as s3.
+	Service string
+
+	// Payload defines the payload signing mode.
+	Payload Payload
+}
+
+// New creates a new plugin signing the outgoing requests with the given signer.
+// Requests are signed before dialing, once the final request has been built.
+func New(signer *Signer) p.Plugin {
+	return p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		if err := signer.Sign(ctx.Request, time.Now()); err != nil {
+			h.Error(ctx, err)
+			return
+		}
+		h.Next(ctx)
+	})
+}
+
+// Sign signs the given request at the given time, adding the Authorization header.
+func (s *Signer) Sign(req *http.Request, t time.Time) error {
+	creds, err := s.Credentials.Retrieve()
+	if err != nil {
+		return err
+	}
+
+	t = t.UTC()
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", t.Format(timeFormat))
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	payload, err := s.payload(req)
+	if err != nil {
+		return err
+	}
+	if s.Service == "s3" || payload == UnsignedPayload || payload == StreamingPayload {
+		req.Header.Set("X-Amz-Content-Sha256", payload)
+	}
+
+	headers, signed := canonicalHeaders(req)
+	signature := s.signature(creds, t, s.canonicalRequest(req, canonicalQuery(req.URL.Query()), headers, signed, payload))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		Algorithm, creds.AccessKeyID, s.scope(t), signed, signature))
+
+	if payload == StreamingPayload {
+		req.Body = newChunkedReader(req.Body, s.key(creds, t), t, s.scope(t), signature)
+	}
+	return nil
+}
+
+// Presign returns the URL of the given request with the signature in the query
+// string, valid for the given time. Only the host header is signed, besides
+// any X-Amz-* headers present in the request, and the payload is unsigned.
+func (s *Signer) Presign(req *http.Request, expires time.Duration, t time.Time) (*url.URL, error) {
+	if expires <= 0 || expires > MaxExpires {
+		return nil, fmt.Errorf("sigv4: invalid presigned URL expiration: %s", expires)
+	}
+
+	creds, err := s.Credentials.Retrieve()
+	if err != nil {
+		return nil, err
+	}
+
+	t = t.UTC()
+	presigned := req.Clone(req.Context())
+	presigned.Header = http.Header{}
+	for name, values := range req.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-") {
+			presigned.Header[name] = values
+		}
+	}
+	headers, signed := canonicalHeaders(presigned)
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", Algorithm)
+	query.Set("X-Amz-Credential", creds.AccessKeyID+"/"+s.scope(t))
+	query.Set("X-Amz-Date", t.Format(timeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", signed)
+	if creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	rawQuery := canonicalQuery(query)
+	signature := s.signature(creds, t, s.canonicalRequest(req, rawQuery, headers, signed, UnsignedPayload))
+
+	u := *req.URL
+	u.RawQuery = rawQuery + "&X-Amz-Signature=" + signature
+	return &u, nil
+}
+
+// payload returns the payload hash based on the signing mode.
+func (s *Signer) payload(req *http.Request) (string, error) {
+	switch s.Payload {
+	case Unsigned:
+		return UnsignedPayload, nil
+
+	case Streaming:
+		if req.ContentLength < 0 || (req.ContentLength > 0 && req.Body == nil) {
+			return "", fmt.Errorf("sigv4: streaming payload requires a known content length")
+		}
+		length := req.ContentLength
+		req.ContentLength = chunkedLength(length)
+		req.Header.Set("Content-Encoding", joinEncoding("aws-chunked", req.Header.Get("Content-Encoding")))
+		req.Header.Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+		req.Header.Set("X-Amz-Decoded-Content-Length", strconv.FormatInt(length, 10))
+		return StreamingPayload, nil
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return emptyHash, nil
+	}
+
+	buf, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	req.ContentLength = int64(len(buf))
+	return hashHex(buf), nil
+}
+
+// canonicalRequest builds the canonical request string.
+func (s *Signer) canonicalRequest(req *http.Request, query, headers, signed, payload string) string {
+	return strings.Join([]string{
+		req.Method,
+		s.canonicalURI(req.URL),
+		query,
+		headers,
+		signed,
+		payload,
+	}, "\n")
+}
+
+// canonicalURI returns the URI-encoded request path.
+// Paths are encoded twice, except for S3 requests.
+func (s *Signer) canonicalURI(u *url.URL) string {
+	path := u.Path
+	if u.Opaque != "" {
+		path = "/" + strings.SplitN(u.Opaque, "/", 4)[3]
+	}
+	if path == "" {
+		return "/"
+	}
+
+	path = escape(path, false)
+	if s.Service != "s3" {
+		path = escape(path, false)
+	}
+	return path
+}
+
+// signature calculates the request signature for the given canonical request.
+func (s *Signer) signature(creds Credentials, t time.Time, canonical string) string {
+	toSign := strings.Join([]string{
+		Algorithm,
+		t.Format(timeFormat),
+		s.scope(t),
+		hashHex([]byte(canonical)),
+	}, "\n")
+	return hex.EncodeToString(hmacSHA256(s.key(creds, t), toSign))
+}
+
+// scope returns the credential scope for the given time.
+func (s *Signer) scope(t time.Time) string {
+	return strings.Join([]string{t.Format(shortFormat), s.Region, s.Service, "aws4_request"}, "/")
+}
+
+// key derives the signing key for the given time.
+func (s *Signer) key(creds Credentials, t time.Time) []byte {
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), t.Format(shortFormat))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// canonicalHeaders returns the canonical headers and the signed headers list.
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	if h, port := splitPort(host); (port == "80" && req.URL.Scheme == "http") || (port == "443" && req.URL.Scheme == "https") {
+		host = h
+	}
+
+	values := map[string]string{"host": host}
+	for name, vals := range req.Header {
+		name = strings.ToLower(name)
+		if ignoredHeaders[name] || name == "host" {
+			continue
+		}
+		trimmed := make([]string, len(vals))
+		for i, value := range vals {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &strings.Builder{}
+	for _, name := range names {
+		buf.WriteString(name + ":" + values[name] + "\n")
+	}
+	return buf.String(), strings.Join(names, ";")
+}
+
+// canonicalQuery returns the canonical query string.
+func canonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, escape(key, true)+"="+escape(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// escape URI-encodes the given string as defined by the signing process,
+// leaving only unreserved characters and, optionally, slashes unencoded.
+func escape(s string, encodeSlash bool) string {
+	buf := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || (ch == '/' && !encodeSlash) {
+			buf.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(buf, "%%%02X", ch)
+	}
+	return buf.String()
+}
+
+// splitPort splits the host and port, if present.
+func splitPort(host string) (string, string) {
+	i := strings.LastIndex(host, ":")
+	if i < 0 || strings.Contains(host[i:], "]") {
+		return host, ""
+	}
+	return host[:i], host[i+1:]
+}
+
+// joinEncoding prepends the given content encoding to the existent ones.
+func joinEncoding(encoding, existent string) string {
+	if existent == "" {
+		return encoding
+	}
+	return encoding + "," + existent
+}
+
+// hashHex returns the hex-encoded SHA256 hash of the given data.
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 calculates the HMAC-SHA256 of the given data.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
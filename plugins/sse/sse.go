@@ -0,0 +1,327 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lytics/gentleman"
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+const (
+	// MIMEType defines the EventSource stream MIME type.
+	MIMEType = "text/event-stream"
+
+	// RetryWait defines the default amount of time to wait before reconnecting.
+	RetryWait = 3 * time.Second
+)
+
+var (
+	// ErrNoContent is returned when the server asks the client to stop reconnecting.
+	ErrNoContent = errors.New("sse: server responded with no content")
+
+	// ErrMaxRetries is returned when the maximum number of consecutive reconnections is exceeded.
+	ErrMaxRetries = errors.New("sse: maximum reconnection attempts exceeded")
+)
+
+// Event represents a Server-Sent Event.
+type Event struct {
+	// ID stores the last event ID received in the stream.
+	ID string
+
+	// Event stores the event type. Defaults to "message".
+	Event string
+
+	// Data stores the event data. Multiple data lines are joined with a line feed.
+	Data string
+
+	// Retry stores the last reconnection time sent by the server, if any.
+	Retry time.Duration
+}
+
+// Options defines the EventSource stream options.
+type Options struct {
+	// LastEventID defines the initial Last-Event-ID header value.
+	LastEventID string
+
+	// Retry defines the default amount of time to wait before reconnecting.
+	// The server can override it via the retry field.
+	Retry time.Duration
+
+	// MaxRetries defines the maximum number of consecutive failed reconnections.
+	// Zero means unlimited reconnections.
+	MaxRetries int
+
+	// Buffer defines the events channel buffer size.
+	Buffer int
+}
+
+// Accept defines the required headers to consume an EventSource stream.
+func Accept(lastEventID string) p.Plugin {
+	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
+		ctx.Request.Header.Set("Accept", MIMEType)
+		ctx.Request.Header.Set("Cache-Control", "no-cache")
+		if lastEventID != "" {
+			ctx.Request.Header.Set("Last-Event-ID", lastEventID)
+		}
+		h.Next(ctx)
+	})
+}
+
+// EventSource represents a Server-Sent Events stream consumed via gentleman.
+// Every connection is dispatched through a clone of the given Request,
+// inheriting the Client middleware stack.
+type EventSource struct {
+	// req stores the template request cloned on every connection
+	req *gentleman.Request
+
+	// opts stores the stream options
+	opts Options
+
+	// events stores the events channel
+	events chan *Event
+
+	// cancel cancels the stream context
+	cancel context.CancelFunc
+
+	// done is closed once the stream stops
+	done chan struct{}
+
+	// mtx protects lastID
+	mtx sync.Mutex
+
+	// lastID and retry store the reconnection state
+	lastID string
+	retry  time.Duration
+
+	// err stores the terminal stream error
+	err error
+}
+
+// Subscribe connects to the EventSource stream defined by the given Request,
+// which must not be dispatched, and starts delivering events.
+// The stream reconnects automatically until the given context is done or Close is called.
+func Subscribe(ctx context.Context, req *gentleman.Request, opts Options) *EventSource {
+	if opts.Retry == 0 {
+		opts.Retry = RetryWait
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &EventSource{
+		req:    req,
+		opts:   opts,
+		events: make(chan *Event, opts.Buffer),
+		cancel: cancel,
+		done:   make(chan struct{}),
+		lastID: opts.LastEventID,
+		retry:  opts.Retry,
+	}
+
+	go s.run(ctx)
+	return s
+}
+
+// Events returns the channel of received events.
+// The channel is closed once the stream stops.
+func (s *EventSource) Events() <-chan *Event {
+	return s.events
+}
+
+// Close stops the stream, closing the active connection.
+func (s *EventSource) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// Err returns the error that stopped the stream, if any.
+// Must be called once the events channel is closed.
+func (s *EventSource) Err() error {
+	<-s.done
+	return s.err
+}
+
+// LastEventID returns the last event ID received in the stream.
+func (s *EventSource) LastEventID() string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.lastID
+}
+
+func (s *EventSource) run(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.events)
+
+	failures := 0
+	for {
+		received, err := s.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == ErrNoContent {
+			s.err = err
+			return
+		}
+
+		// Reset the failures counter if the connection was healthy
+		if err == nil || received {
+			failures = 0
+		} else {
+			failures++
+		}
+		if s.opts.MaxRetries > 0 && failures > s.opts.MaxRetries {
+			s.err = fmt.Errorf("%s: %s", ErrMaxRetries, err)
+			return
+		}
+
+		timer := time.NewTimer(s.retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// connect dispatches a new connection and consumes the stream until it ends.
+// Returns true if at least one event was received.
+func (s *EventSource) connect(ctx context.Context) (bool, error) {
+	req := s.req.Clone()
+	req.Use(Accept(s.LastEventID()))
+
+	res, err := req.DoContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	if res.StatusCode == 204 {
+		res.Close()
+		return false, ErrNoContent
+	}
+	if res.StatusCode != 200 {
+		res.Close()
+		return false, fmt.Errorf("sse: invalid response status: %d", res.StatusCode)
+	}
+	if kind := res.Header.Get("Content-Type"); !strings.HasPrefix(kind, MIMEType) {
+		res.Close()
+		return false, fmt.Errorf("sse: invalid response content type: %s", kind)
+	}
+
+	received := false
+	parser := &parser{}
+	scanner := res.Lines()
+	defer scanner.Close()
+
+	for scanner.Next() {
+		event := parser.parse(scanner.Bytes())
+		if parser.hasID {
+			s.mtx.Lock()
+			s.lastID = parser.id
+			s.mtx.Unlock()
+		}
+		if parser.retry > 0 {
+			s.retry = parser.retry
+		}
+		if event == nil {
+			continue
+		}
+
+		event.ID = s.lastID
+		received = true
+		select {
+		case s.events <- event:
+		case <-ctx.Done():
+			return received, ctx.Err()
+		}
+	}
+
+	return received, scanner.Err()
+}
+
+// parser implements the EventSource stream parsing algorithm.
+type parser struct {
+	// started stores if the first line was already parsed
+	started bool
+
+	// event and data store the current event buffers
+	event string
+	data  bytes.Buffer
+
+	// id stores the last event ID field and if it was defined
+	id    string
+	hasID bool
+
+	// retry stores the last valid reconnection time
+	retry time.Duration
+}
+
+// parse parses the given line, returning the event to dispatch, if any.
+func (ps *parser) parse(line []byte) *Event {
+	if !ps.started {
+		ps.started = true
+		line = bytes.TrimPrefix(line, []byte("\xEF\xBB\xBF"))
+	}
+
+	// Blank line dispatches the event
+	if len(line) == 0 {
+		return ps.dispatch()
+	}
+
+	// Ignore comments
+	if line[0] == ':' {
+		return nil
+	}
+
+	field, value := line, []byte{}
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		field, value = line[:i], line[i+1:]
+		value = bytes.TrimPrefix(value, []byte(" "))
+	}
+
+	switch string(field) {
+	case "event":
+		ps.event = string(value)
+	case "data":
+		ps.data.Write(value)
+		ps.data.WriteByte('\n')
+	case "id":
+		if bytes.IndexByte(value, 0) == -1 {
+			ps.id = string(value)
+			ps.hasID = true
+		}
+	case "retry":
+		if ms, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			ps.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	return nil
+}
+
+// dispatch builds the buffered event and resets the event buffers.
+func (ps *parser) dispatch() *Event {
+	defer func() {
+		ps.event = ""
+		ps.data.Reset()
+	}()
+
+	if ps.data.Len() == 0 {
+		return nil
+	}
+
+	event := &Event{
+		Event: ps.event,
+		Data:  strings.TrimSuffix(ps.data.String(), "\n"),
+		Retry: ps.retry,
+	}
+	if event.Event == "" {
+		event.Event = "message"
+	}
+	return event
+}
@@ -0,0 +1,124 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestParser(t *testing.T) {
+	ps := &parser{}
+	lines := []string{
+		"\xEF\xBB\xBF: comment",
+		"event: update",
+		"data: foo",
+		"data:bar",
+		"id: 1",
+		"retry: 100",
+		"",
+		"data",
+		"",
+		"retry: invalid",
+		"",
+	}
+
+	events := []*Event{}
+	for _, line := range lines {
+		if event := ps.parse([]byte(line)); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	utils.Equal(t, len(events), 2)
+	utils.Equal(t, events[0].Event, "update")
+	utils.Equal(t, events[0].Data, "foo\nbar")
+	utils.Equal(t, events[0].Retry, 100*time.Millisecond)
+	utils.Equal(t, events[1].Event, "message")
+	utils.Equal(t, events[1].Data, "")
+	utils.Equal(t, ps.id, "1")
+}
+
+func TestSubscribe(t *testing.T) {
+	var mtx sync.Mutex
+	calls := 0
+	lastIDs := []string{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		calls++
+		call := calls
+		lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+		mtx.Unlock()
+
+		utils.Equal(t, r.Header.Get("Accept"), MIMEType)
+		utils.Equal(t, r.Header.Get("Authorization"), "Bearer foo")
+
+		if call > 2 {
+			w.WriteHeader(204)
+			return
+		}
+
+		w.Header().Set("Content-Type", MIMEType)
+		fmt.Fprintf(w, "retry: 10\nid: %d\ndata: hello %d\n\n", call, call)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.SetHeader("Authorization", "Bearer foo")
+
+	stream := Subscribe(context.Background(), cli.Request().URL(ts.URL), Options{})
+
+	events := []*Event{}
+	for event := range stream.Events() {
+		events = append(events, event)
+	}
+
+	utils.Equal(t, stream.Err(), ErrNoContent)
+	utils.Equal(t, len(events), 2)
+	utils.Equal(t, events[0].ID, "1")
+	utils.Equal(t, events[0].Data, "hello 1")
+	utils.Equal(t, events[1].ID, "2")
+	utils.Equal(t, events[1].Data, "hello 2")
+	utils.Equal(t, lastIDs, []string{"", "1", "2"})
+	utils.Equal(t, stream.LastEventID(), "2")
+}
+
+func TestSubscribeMaxRetries(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	opts := Options{Retry: 10 * time.Millisecond, MaxRetries: 2}
+	stream := Subscribe(context.Background(), gentleman.NewRequest().URL(ts.URL), opts)
+
+	_, ok := <-stream.Events()
+	utils.Equal(t, ok, false)
+	utils.Equal(t, stream.Err().Error(), "sse: maximum reconnection attempts exceeded: sse: invalid response status: 503")
+}
+
+func TestSubscribeClose(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MIMEType)
+		fmt.Fprint(w, "data: hello\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	stream := Subscribe(context.Background(), gentleman.NewRequest().URL(ts.URL), Options{})
+	event := <-stream.Events()
+	utils.Equal(t, event.Data, "hello")
+
+	stream.Close()
+	_, ok := <-stream.Events()
+	utils.Equal(t, ok, false)
+	utils.Equal(t, stream.Err(), nil)
+}
@@ -0,0 +1,190 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+// ProblemType defines the problem details document media type.
+const ProblemType = "application/problem+json"
+
+// ProblemLimit defines the maximum number of response body bytes
+// decoded as a problem details document.
+var ProblemLimit int64 = 1 << 20
+
+var (
+	// ErrNotProblem is returned if the response is not a problem details document.
+	ErrNotProblem = errors.New("status: response is not a problem details document")
+
+	// ErrProblemTooLarge is returned if the problem details document exceeds ProblemLimit.
+	ErrProblemTooLarge = errors.New("status: problem details document exceeds ProblemLimit")
+)
+
+// ProblemFunc creates a custom error based on a problem details document.
+type ProblemFunc func(*Problem) error
+
+var (
+	// mtx protects the problem registries
+	mtx sync.RWMutex
+
+	// problemTypes stores the registered errors by problem type URI
+	problemTypes = map[string]ProblemFunc{}
+
+	// problemStatus stores the registered errors by status code
+	problemStatus = map[int]ProblemFunc{}
+)
+
+// Problem represents a problem details document as defined in RFC 9457.
+// Implements the error interface.
+type Problem struct {
+	// Type stores the URI reference identifying the problem type.
+	Type string `json:"type,omitempty"`
+
+	// Title stores a short, human-readable summary of the problem type.
+	Title string `json:"title,omitempty"`
+
+	// Status stores the HTTP status code generated by the origin server.
+	Status int `json:"status,omitempty"`
+
+	// Detail stores a human-readable explanation specific to this occurrence.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance stores the URI reference identifying this occurrence.
+	Instance string `json:"instance,omitempty"`
+
+	// Extensions stores the problem type specific extension members.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// problem is used to decode the standard members without recursion.
+type problem Problem
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*problem)(p)); err != nil {
+		return err
+	}
+
+	members := map[string]interface{}{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	for _, name := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(members, name)
+	}
+
+	p.Extensions = nil
+	if len(members) > 0 {
+		p.Extensions = members
+	}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := map[string]interface{}{}
+	for name, value := range p.Extensions {
+		members[name] = value
+	}
+
+	buf, err := json.Marshal((*problem)(p))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// Error implements the error interface.
+func (p *Problem) Error() string {
+	msg := p.Title
+	if msg == "" {
+		msg = p.Type
+	}
+	if msg == "" {
+		msg = http.StatusText(p.Status)
+	}
+	if p.Detail != "" {
+		msg += ": " + p.Detail
+	}
+	return "problem: " + msg
+}
+
+// Err returns the error registered for the problem type URI or status code,
+// or the Problem itself if there is no registered error.
+func (p *Problem) Err() error {
+	return p.err(p.Status)
+}
+
+// err returns the registered error, using the given status code
+// as fallback if the problem does not define it.
+func (p *Problem) err(code int) error {
+	if p.Status != 0 {
+		code = p.Status
+	}
+
+	mtx.RLock()
+	fn, ok := problemTypes[p.Type]
+	if !ok || p.Type == "" {
+		fn, ok = problemStatus[code]
+	}
+	mtx.RUnlock()
+
+	if !ok {
+		return p
+	}
+	return fn(p)
+}
+
+// RegisterType registers a custom error factory for the given problem type URI.
+func RegisterType(uri string, fn ProblemFunc) {
+	mtx.Lock()
+	problemTypes[uri] = fn
+	mtx.Unlock()
+}
+
+// RegisterStatus registers a custom error factory for problems with the given status code.
+// Problem type registrations have precedence over status code registrations.
+func RegisterStatus(code int, fn ProblemFunc) {
+	mtx.Lock()
+	problemStatus[code] = fn
+	mtx.Unlock()
+}
+
+// IsProblem returns true if the given headers define a problem details document Content-Type.
+func IsProblem(header http.Header) bool {
+	kind, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return kind == ProblemType
+}
+
+// ParseProblem parses the given JSON problem details document.
+func ParseProblem(data []byte) (*Problem, error) {
+	p := &Problem{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ReadProblem decodes the problem details document of the given response,
+// reading up to ProblemLimit bytes of the body.
+// The read bytes are restored in the response body to be consumed again.
+func ReadProblem(res *http.Response) (*Problem, error) {
+	if !IsProblem(res.Header) {
+		return nil, ErrNotProblem
+	}
+	return readProblem(peekBody(res, ProblemLimit+1))
+}
+
+// readProblem decodes the given body bytes, read up to ProblemLimit+1 bytes.
+func readProblem(data []byte) (*Problem, error) {
+	if int64(len(data)) > ProblemLimit {
+		return nil, ErrProblemTooLarge
+	}
+	return ParseProblem(data)
+}
@@ -0,0 +1,137 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/utils"
+)
+
+const problemDocument = `{
+	"type": "https://example.com/probs/out-of-credit",
+	"title": "You do not have enough credit.",
+	"status": 403,
+	"detail": "Your current balance is 30, but that costs 50.",
+	"instance": "/account/12345/msgs/abc",
+	"balance": 30
+}`
+
+type creditError struct {
+	Balance float64
+}
+
+func (e *creditError) Error() string {
+	return "not enough credit"
+}
+
+type notFoundError struct{}
+
+func (e *notFoundError) Error() string {
+	return "not found"
+}
+
+func TestParseProblem(t *testing.T) {
+	problem, err := ParseProblem([]byte(problemDocument))
+	utils.Equal(t, err, nil)
+	utils.Equal(t, problem.Type, "https://example.com/probs/out-of-credit")
+	utils.Equal(t, problem.Title, "You do not have enough credit.")
+	utils.Equal(t, problem.Status, 403)
+	utils.Equal(t, problem.Detail, "Your current balance is 30, but that costs 50.")
+	utils.Equal(t, problem.Instance, "/account/12345/msgs/abc")
+	utils.Equal(t, problem.Extensions, map[string]interface{}{"balance": float64(30)})
+	utils.Equal(t, problem.Error(), "problem: You do not have enough credit.: Your current balance is 30, but that costs 50.")
+
+	buf, err := json.Marshal(problem)
+	utils.Equal(t, err, nil)
+	decoded, err := ParseProblem(buf)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, decoded, problem)
+
+	_, err = ParseProblem([]byte("foo"))
+	utils.NotEqual(t, err, nil)
+}
+
+func TestReadProblem(t *testing.T) {
+	res := &http.Response{StatusCode: 404, Header: http.Header{}}
+	res.Header.Set("Content-Type", "application/problem+json")
+	utils.WriteBodyString(res, `{"title":"Not found","status":404,"resource":"foo"}`)
+
+	problem, err := ReadProblem(res)
+	utils.Equal(t, err, nil)
+	utils.Equal(t, problem.Title, "Not found")
+	utils.Equal(t, problem.Extensions["resource"], "foo")
+	buf, _ := ioutil.ReadAll(res.Body)
+	utils.Equal(t, string(buf), `{"title":"Not found","status":404,"resource":"foo"}`)
+
+	res = &http.Response{StatusCode: 404, Header: http.Header{}}
+	utils.WriteBodyString(res, `{"title":"Not found"}`)
+	_, err = ReadProblem(res)
+	utils.Equal(t, err, ErrNotProblem)
+
+	defer func(limit int64) { ProblemLimit = limit }(ProblemLimit)
+	ProblemLimit = 10
+	res = &http.Response{StatusCode: 404, Header: http.Header{}}
+	res.Header.Set("Content-Type", "application/problem+json")
+	utils.WriteBodyString(res, `{"title":"Not found"}`)
+	_, err = ReadProblem(res)
+	utils.Equal(t, err, ErrProblemTooLarge)
+}
+
+func TestProblemRegistry(t *testing.T) {
+	RegisterType("https://example.com/probs/out-of-credit", func(p *Problem) error {
+		balance, _ := p.Extensions["balance"].(float64)
+		return &creditError{balance}
+	})
+	RegisterStatus(404, func(p *Problem) error {
+		return &notFoundError{}
+	})
+
+	problem, _ := ParseProblem([]byte(problemDocument))
+	var creditErr *creditError
+	utils.Equal(t, errors.As(problem.Err(), &creditErr), true)
+	utils.Equal(t, creditErr.Balance, float64(30))
+
+	problem = &Problem{Type: "https://example.com/probs/unknown", Status: 404}
+	var notFoundErr *notFoundError
+	utils.Equal(t, errors.As(problem.Err(), &notFoundErr), true)
+
+	problem = &Problem{Status: 500}
+	utils.Equal(t, problem.Err(), problem)
+}
+
+func TestProblems(t *testing.T) {
+	RegisterType("https://example.com/probs/out-of-credit", func(p *Problem) error {
+		return &creditError{}
+	})
+
+	ctx := context.New()
+	utils.ReplyWithStatus(ctx.Response, 403)
+	ctx.Response.Header.Set("Content-Type", "application/problem+json; charset=utf-8")
+	utils.WriteBodyString(ctx.Response, problemDocument)
+
+	fn := newHandler()
+	Problems().Exec("response", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+
+	var httpErr *HTTPError
+	utils.Equal(t, errors.As(ctx.Error, &httpErr), true)
+	utils.NotEqual(t, httpErr.Problem, nil)
+	var creditErr *creditError
+	utils.Equal(t, errors.As(ctx.Error, &creditErr), true)
+	var problem *Problem
+	utils.Equal(t, errors.As(ctx.Error, &problem), true)
+	utils.Equal(t, problem, httpErr.Problem)
+
+	ctx = context.New()
+	utils.ReplyWithStatus(ctx.Response, 400)
+	ctx.Response.Header.Set("Content-Type", "application/json")
+	fn = newHandler()
+	Problems().Exec("response", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+	utils.Equal(t, ctx.Error, nil)
+	utils.Equal(t, IsProblem(http.Header{}), false)
+}
@@ -0,0 +1,190 @@
+package status
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// BodyLimit defines the maximum number of response body bytes
+// captured by HTTPError.
+var BodyLimit int64 = 4096
+
+// Range represents an inclusive range of HTTP status codes.
+type Range struct {
+	// Min stores the first status code of the range.
+	Min int
+
+	// Max stores the last status code of the range.
+	Max int
+}
+
+var (
+	// ClientErrors matches 4xx status codes.
+	ClientErrors = Range{400, 499}
+
+	// ServerErrors matches 5xx status codes.
+	ServerErrors = Range{500, 599}
+)
+
+// Contains returns true if the given status code is within the range.
+func (r Range) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+// HTTPError represents an HTTP response with a failed status code.
+type HTTPError struct {
+	// StatusCode stores the response status code.
+	StatusCode int
+
+	// Status stores the response status line text.
+	Status string
+
+	// Method stores the request method.
+	Method string
+
+	// URL stores the request URL.
+	URL string
+
+	// Header stores the response headers.
+	Header http.Header
+
+	// Body stores the first bytes of the response body, up to BodyLimit.
+	Body []byte
+
+	// Problem stores the decoded problem details document, if present
+	// and not larger than ProblemLimit.
+	Problem *Problem
+}
+
+// NewError creates a new HTTPError based on the given request and response.
+// The captured body bytes are restored in the response body.
+func NewError(req *http.Request, res *http.Response) *HTTPError {
+	err, _ := newError(req, res)
+	return err
+}
+
+// newError creates a new HTTPError, also returning the read body bytes.
+// Problem details documents are decoded from the body up to ProblemLimit,
+// instead of the BodyLimit snippet.
+func newError(req *http.Request, res *http.Response) (*HTTPError, []byte) {
+	err := &HTTPError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     res.Header,
+	}
+
+	var buf []byte
+	if IsProblem(res.Header) {
+		buf = peekBody(res, ProblemLimit+1)
+		err.Problem, _ = readProblem(buf)
+	} else {
+		buf = peekBody(res, BodyLimit)
+	}
+	err.Body = buf
+	if int64(len(buf)) > BodyLimit {
+		err.Body = buf[:BodyLimit]
+	}
+
+	if req != nil {
+		err.Method = req.Method
+		err.URL = req.URL.String()
+	}
+	if err.Status == "" {
+		err.Status = fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	return err, buf
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("gentleman: %s %s: %s", e.Method, e.URL, e.Status)
+	if e.Problem != nil && e.Problem.Title != "" {
+		msg += ": " + e.Problem.Title
+	}
+	if e.Problem != nil && e.Problem.Detail != "" {
+		msg += ": " + e.Problem.Detail
+	}
+	return msg
+}
+
+// Unwrap returns the error registered for the problem details document,
+// if any, and the Problem itself.
+// This allows to use errors.As with either custom problem error types or *Problem.
+func (e *HTTPError) Unwrap() []error {
+	if e.Problem == nil {
+		return nil
+	}
+	if err := e.Problem.err(e.StatusCode); err != error(e.Problem) {
+		return []error{err, e.Problem}
+	}
+	return []error{e.Problem}
+}
+
+// Fail reports an HTTPError via the error middleware phase if the response
+// status code is within any of the given ranges.
+// By default, 4xx and 5xx status codes are considered failures.
+func Fail(ranges ...Range) p.Plugin {
+	if len(ranges) == 0 {
+		ranges = []Range{ClientErrors, ServerErrors}
+	}
+
+	return p.NewResponsePlugin(func(ctx *c.Context, h c.Handler) {
+		for _, r := range ranges {
+			if r.Contains(ctx.Response.StatusCode) {
+				h.Error(ctx, fail(ctx))
+				return
+			}
+		}
+		h.Next(ctx)
+	})
+}
+
+// Problems reports responses with a problem details document body
+// as HTTPError via the error middleware phase, regardless of the status code.
+func Problems() p.Plugin {
+	return p.NewResponsePlugin(func(ctx *c.Context, h c.Handler) {
+		if IsProblem(ctx.Response.Header) {
+			h.Error(ctx, fail(ctx))
+			return
+		}
+		h.Next(ctx)
+	})
+}
+
+// fail creates the HTTPError of the context response, closing the original body
+// to release the connection. The response body is replaced by the read bytes,
+// in case the error is recovered by the error middleware phase.
+func fail(ctx *c.Context) *HTTPError {
+	original := ctx.Response.Body
+	err, buf := newError(ctx.Request, ctx.Response)
+	if original != nil {
+		original.Close()
+		ctx.Response.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	}
+	return err
+}
+
+// peekBody reads up to limit bytes of the response body, restoring them
+// in the response body to be consumed again.
+func peekBody(res *http.Response, limit int64) []byte {
+	if res.Body == nil {
+		return nil
+	}
+
+	buf, _ := ioutil.ReadAll(io.LimitReader(res.Body, limit))
+	res.Body = &readCloser{io.MultiReader(bytes.NewReader(buf), res.Body), res.Body}
+	return buf
+}
+
+// readCloser joins an io.Reader with an io.Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,115 @@
+package status
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestFail(t *testing.T) {
+	ctx := context.New()
+	ctx.Request.Method = "POST"
+	ctx.Request.URL.Scheme = "http"
+	ctx.Request.URL.Host = "foo.com"
+	utils.ReplyWithStatus(ctx.Response, 404)
+	utils.WriteBodyString(ctx.Response, "not found")
+
+	fn := newHandler()
+	Fail().Exec("response", ctx, fn.fn)
+	utils.Equal(t, fn.called, true)
+	utils.NotEqual(t, ctx.Error, nil)
+
+	err, ok := ctx.Error.(*HTTPError)
+	utils.Equal(t, ok, true)
+	utils.Equal(t, err.StatusCode, 404)
+	utils.Equal(t, err.Method, "POST")
+	utils.Equal(t, err.URL, "http://foo.com")
+	utils.Equal(t, string(err.Body), "not found")
+	utils.Equal(t, err.Error(), "gentleman: POST http://foo.com: 404 Not Found")
+
+	// Body must be still readable
+	body, _ := ioutil.ReadAll(ctx.Response.Body)
+	utils.Equal(t, string(body), "not found")
+}
+
+func TestFailRanges(t *testing.T) {
+	cases := []struct {
+		code   int
+		ranges []Range
+		failed bool
+	}{
+		{200, nil, false},
+		{302, nil, false},
+		{400, nil, true},
+		{503, nil, true},
+		{404, []Range{ServerErrors}, false},
+		{503, []Range{ServerErrors}, true},
+		{302, []Range{{300, 399}}, true},
+	}
+
+	for _, test := range cases {
+		ctx := context.New()
+		ctx.Response.StatusCode = test.code
+		fn := newHandler()
+		Fail(test.ranges...).Exec("response", ctx, fn.fn)
+		utils.Equal(t, fn.called, true)
+		utils.Equal(t, ctx.Error != nil, test.failed)
+	}
+}
+
+func TestNewErrorProblem(t *testing.T) {
+	res := &http.Response{StatusCode: 403, Header: http.Header{}}
+	res.Header.Set("Content-Type", "application/problem+json")
+	utils.WriteBodyString(res, `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","status":403,"detail":"Your current balance is 30."}`)
+	req := httptest.NewRequest("GET", "http://foo.com/account", nil)
+
+	err := NewError(req, res)
+	utils.NotEqual(t, err.Problem, nil)
+	utils.Equal(t, err.Problem.Type, "https://example.com/probs/out-of-credit")
+	utils.Equal(t, err.Problem.Status, 403)
+	utils.Equal(t, err.Error(), "gentleman: GET http://foo.com/account: 403 Forbidden: You do not have enough credit.: Your current balance is 30.")
+}
+
+func TestNewErrorBodyLimit(t *testing.T) {
+	res := &http.Response{StatusCode: 500, Header: http.Header{}}
+	body := strings.Repeat("x", int(BodyLimit)+10)
+	utils.WriteBodyString(res, body)
+
+	err := NewError(nil, res)
+	utils.Equal(t, len(err.Body), int(BodyLimit))
+	utils.Equal(t, errors.Is(err, err), true)
+
+	buf, _ := ioutil.ReadAll(res.Body)
+	utils.Equal(t, string(buf), body)
+}
+
+func TestNewErrorProblemBodyLimit(t *testing.T) {
+	detail := strings.Repeat("x", int(BodyLimit))
+	res := &http.Response{StatusCode: 400, Header: http.Header{}}
+	res.Header.Set("Content-Type", "application/problem+json")
+	utils.WriteBodyString(res, `{"title":"Invalid","detail":"`+detail+`"}`)
+
+	err := NewError(nil, res)
+	utils.Equal(t, len(err.Body), int(BodyLimit))
+	utils.NotEqual(t, err.Problem, nil)
+	utils.Equal(t, err.Problem.Detail, detail)
+}
+
+type handler struct {
+	fn     context.Handler
+	called bool
+}
+
+func newHandler() *handler {
+	h := &handler{}
+	h.fn = context.NewHandler(func(c *context.Context) {
+		h.called = true
+	})
+	return h
+}
@@ -76,10 +76,10 @@ func defineTimeouts(timeouts Timeouts, ctx *c.Context) {
 		timeouts.KeepAlive = g.DialKeepAlive
 	}
 
-	transport.Dial = (&net.Dialer{
+	transport.DialContext = (&net.Dialer{
 		Timeout:   timeouts.Dial,
 		KeepAlive: timeouts.KeepAlive,
-	}).Dial
+	}).DialContext
 
 	// Finally expose the transport to be used
 	ctx.Client.Transport = transport
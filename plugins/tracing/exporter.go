@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanKind represents the role of a span in the trace.
+type SpanKind string
+
+const (
+	// SpanKindInternal defines the kind of the span covering the whole request,
+	// including the middleware phases, retries and redirects.
+	SpanKindInternal SpanKind = "internal"
+
+	// SpanKindClient defines the kind of the spans covering each round trip.
+	SpanKindClient SpanKind = "client"
+)
+
+// Span represents a timed operation of a trace.
+type Span struct {
+	// Name stores the span name, such as "HTTP GET".
+	Name string
+
+	// Kind stores the span kind.
+	Kind SpanKind
+
+	// SpanContext stores the span identity.
+	SpanContext SpanContext
+
+	// Parent stores the parent span ID, if any.
+	Parent SpanID
+
+	// Start stores the span start time.
+	Start time.Time
+
+	// End stores the span end time.
+	End time.Time
+
+	// Attributes stores the span attributes, following the
+	// OpenTelemetry HTTP semantic conventions naming.
+	Attributes map[string]interface{}
+
+	// Err stores the error which failed the operation, if any.
+	Err error
+}
+
+// Duration returns the span duration.
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Exporter represents the interface implemented by span exporters.
+// Only sampled spans are exported, once ended.
+type Exporter interface {
+	// ExportSpan reports the given ended span.
+	ExportSpan(*Span)
+}
+
+// MemoryExporter stores the exported spans in memory.
+type MemoryExporter struct {
+	// mtx protects spans
+	mtx sync.Mutex
+
+	// spans stores the exported spans
+	spans []*Span
+}
+
+// NewMemoryExporter creates a new in-memory span exporter.
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+// ExportSpan implements the Exporter interface.
+func (e *MemoryExporter) ExportSpan(span *Span) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans, in export order.
+// Child spans are exported before their parent.
+func (e *MemoryExporter) Spans() []*Span {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	spans := make([]*Span, len(e.spans))
+	copy(spans, e.spans)
+	return spans
+}
+
+// Reset removes the exported spans.
+func (e *MemoryExporter) Reset() {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.spans = nil
+}
+
+// LogExporter writes the exported spans as logfmt lines.
+type LogExporter struct {
+	// mtx protects writer
+	mtx sync.Mutex
+
+	// writer stores the output writer
+	writer io.Writer
+}
+
+// NewLogExporter creates a new span exporter writing into the given writer.
+func NewLogExporter(w io.Writer) *LogExporter {
+	return &LogExporter{writer: w}
+}
+
+// ExportSpan implements the Exporter interface.
+func (e *LogExporter) ExportSpan(span *Span) {
+	fields := []string{
+		"span=" + quote(span.Name),
+		"kind=" + string(span.Kind),
+		"trace_id=" + span.SpanContext.TraceID.String(),
+		"span_id=" + span.SpanContext.SpanID.String(),
+	}
+	if span.Parent.IsValid() {
+		fields = append(fields, "parent_id="+span.Parent.String())
+	}
+	fields = append(fields, "duration="+span.Duration().String())
+
+	keys := make([]string, 0, len(span.Attributes))
+	for key := range span.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, key+"="+quote(fmt.Sprint(span.Attributes[key])))
+	}
+	if span.Err != nil {
+		fields = append(fields, "error="+quote(span.Err.Error()))
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	fmt.Fprintln(e.writer, strings.Join(fields, " "))
+}
+
+// quote quotes the given logfmt value if needed.
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " \"=\t\n") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -0,0 +1,242 @@
+package tracing
+
+import (
+	gocontext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	// TraceparentHeader defines the W3C trace context parent header.
+	TraceparentHeader = "Traceparent"
+
+	// TracestateHeader defines the W3C trace context vendor state header.
+	TracestateHeader = "Tracestate"
+
+	// BaggageHeader defines the W3C baggage header.
+	BaggageHeader = "Baggage"
+
+	// FlagSampled defines the trace flag reporting that the trace is sampled.
+	FlagSampled byte = 0x01
+)
+
+// ErrInvalidTraceparent is returned when a traceparent value cannot be parsed.
+var ErrInvalidTraceparent = errors.New("tracing: invalid traceparent")
+
+// TraceID represents a W3C trace identifier.
+type TraceID [16]byte
+
+// IsValid reports whether the trace ID is not zero.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String returns the lowercase hex encoding of the trace ID.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID represents a W3C span identifier.
+type SpanID [8]byte
+
+// IsValid reports whether the span ID is not zero.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// String returns the lowercase hex encoding of the span ID.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext represents the propagated identity of a span.
+type SpanContext struct {
+	// TraceID stores the trace identifier.
+	TraceID TraceID
+
+	// SpanID stores the span identifier.
+	SpanID SpanID
+
+	// Flags stores the trace flags.
+	Flags byte
+
+	// TraceState stores the opaque vendor specific tracestate value.
+	TraceState string
+}
+
+// IsValid reports whether both trace and span IDs are valid.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled reports whether the sampled flag is set.
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// Traceparent returns the traceparent header value of the span context.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ParseTraceparent parses the given traceparent header value.
+// Values of future versions are accepted as long as they start with
+// the version 00 fields, as mandated by the specification.
+func ParseTraceparent(value string) (SpanContext, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	version, err := decodeHex(value[:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(value) != 55) || (len(value) > 55 && value[55] != '-') {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var sc SpanContext
+	traceID, err := decodeHex(value[3:35])
+	if err != nil {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	spanID, err := decodeHex(value[36:52])
+	if err != nil {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	flags, err := decodeHex(value[53:55])
+	if err != nil {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// decodeHex decodes the given lowercase hex string.
+func decodeHex(s string) ([]byte, error) {
+	if strings.ToLower(s) != s {
+		return nil, ErrInvalidTraceparent
+	}
+	return hex.DecodeString(s)
+}
+
+// Baggage represents the W3C baggage key-value pairs propagated with the trace.
+type Baggage map[string]string
+
+// String returns the baggage header value, sorted by key.
+func (b Baggage) String() string {
+	keys := make([]string, 0, len(b))
+	for key := range b {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	members := make([]string, len(keys))
+	for i, key := range keys {
+		members[i] = key + "=" + url.PathEscape(b[key])
+	}
+	return strings.Join(members, ",")
+}
+
+// ParseBaggage parses the given baggage header value.
+// Member properties and invalid members are ignored.
+func ParseBaggage(value string) Baggage {
+	baggage := Baggage{}
+	for _, member := range strings.Split(value, ",") {
+		member = strings.TrimSpace(strings.SplitN(member, ";", 2)[0])
+		parts := strings.SplitN(member, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		val, err := url.PathUnescape(strings.TrimSpace(parts[1]))
+		if key == "" || err != nil {
+			continue
+		}
+		baggage[key] = val
+	}
+	return baggage
+}
+
+// Inject sets the trace context and baggage headers of the given span context.
+func Inject(header http.Header, sc SpanContext, baggage Baggage) {
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	} else {
+		header.Del(TracestateHeader)
+	}
+	if len(baggage) > 0 {
+		header.Set(BaggageHeader, baggage.String())
+	}
+}
+
+// Extract returns the span context and baggage propagated in the given headers.
+// The returned span context is invalid if the traceparent header is missing or invalid.
+func Extract(header http.Header) (SpanContext, Baggage) {
+	baggage := ParseBaggage(strings.Join(header.Values(BaggageHeader), ","))
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return SpanContext{}, baggage
+	}
+	sc.TraceState = strings.Join(header.Values(TracestateHeader), ",")
+	return sc, baggage
+}
+
+// contextKey represents the context.Context keys of the package.
+type contextKey int
+
+const (
+	spanContextKey contextKey = iota
+	baggageKey
+)
+
+// ContextWithSpanContext returns a copy of the given context.Context
+// carrying the given span context as parent of the outgoing requests.
+func ContextWithSpanContext(ctx gocontext.Context, sc SpanContext) gocontext.Context {
+	return gocontext.WithValue(ctx, spanContextKey, sc)
+}
+
+// SpanContextFromContext returns the span context carried by the given context.Context.
+func SpanContextFromContext(ctx gocontext.Context) SpanContext {
+	sc, _ := ctx.Value(spanContextKey).(SpanContext)
+	return sc
+}
+
+// ContextWithBaggage returns a copy of the given context.Context
+// carrying the given baggage to propagate in the outgoing requests.
+func ContextWithBaggage(ctx gocontext.Context, baggage Baggage) gocontext.Context {
+	return gocontext.WithValue(ctx, baggageKey, baggage)
+}
+
+// BaggageFromContext returns the baggage carried by the given context.Context.
+func BaggageFromContext(ctx gocontext.Context) Baggage {
+	baggage, _ := ctx.Value(baggageKey).(Baggage)
+	return baggage
+}
+
+// newTraceID generates a new random trace ID.
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// newSpanID generates a new random span ID.
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
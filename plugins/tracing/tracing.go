@@ -0,0 +1,194 @@
+package tracing
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	c "github.com/lytics/gentleman/context"
+	p "github.com/lytics/gentleman/plugin"
+)
+
+// SpanKey stores the context store key of the request span.
+const SpanKey = "$tracing.span"
+
+// Options represents the tracing plugin options.
+type Options struct {
+	// Exporter defines the exporter of the sampled spans.
+	// If nil, the trace context is propagated but no span is exported.
+	Exporter Exporter
+
+	// Sampler decides whether the traces started by the client are sampled.
+	// Requests with a parent span inherit its sampling decision.
+	// If nil, every trace is sampled.
+	Sampler func(*http.Request) bool
+}
+
+// GetSpan returns the span of the request stored in the given Context, if present.
+// It is available since the request phase, and can be used to add custom attributes
+// before the span ends in the response or error phase.
+func GetSpan(ctx *c.Context) *Span {
+	if tr, ok := ctx.Get(SpanKey).(*trace); ok {
+		return tr.span
+	}
+	return nil
+}
+
+// New creates a new tracing plugin which creates a span per request, as child of
+// the span context carried by the request context.Context, if any, and a child span
+// per round trip, including retry attempts and redirects, injecting the W3C
+// traceparent, tracestate and baggage headers of the round trip span.
+// Retry attempts are traced as long as the plugin is registered before the retry plugin.
+func New(opts Options) p.Plugin {
+	plu := p.New()
+	plu.SetHandlers(p.Handlers{
+		"request": func(ctx *c.Context, h c.Handler) {
+			start(ctx, opts)
+			h.Next(ctx)
+		},
+		"before dial": func(ctx *c.Context, h c.Handler) {
+			if tr, ok := ctx.Get(SpanKey).(*trace); ok {
+				ctx.Client.Transport = &Transport{tr, ctx.Client.Transport}
+			}
+			h.Next(ctx)
+		},
+		"response": func(ctx *c.Context, h c.Handler) {
+			end(ctx, nil)
+			h.Next(ctx)
+		},
+		"error": func(ctx *c.Context, h c.Handler) {
+			end(ctx, ctx.Error)
+			h.Next(ctx)
+		},
+	})
+	return plu
+}
+
+// trace stores the tracing state of a request.
+type trace struct {
+	// mtx protects the span and round trips
+	mtx sync.Mutex
+
+	// span stores the request span
+	span *Span
+
+	// baggage stores the propagated baggage
+	baggage Baggage
+
+	// exporter stores the span exporter
+	exporter Exporter
+
+	// roundTrips stores the number of started round trips
+	roundTrips int
+
+	// ended reports whether the request span ended
+	ended bool
+}
+
+// start starts the request span.
+func start(ctx *c.Context, opts Options) {
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Flags: parent.Flags, TraceState: parent.TraceState}
+	if !parent.IsValid() {
+		sc = SpanContext{TraceID: newTraceID(), SpanID: sc.SpanID}
+		if opts.Sampler == nil || opts.Sampler(ctx.Request) {
+			sc.Flags = FlagSampled
+		}
+	}
+
+	span := &Span{Kind: SpanKindInternal, SpanContext: sc, Parent: parent.SpanID, Start: time.Now(), Attributes: map[string]interface{}{}}
+	ctx.Set(SpanKey, &trace{span: span, baggage: BaggageFromContext(ctx), exporter: opts.Exporter})
+}
+
+// end ends and exports the request span, once.
+func end(ctx *c.Context, err error) {
+	tr, ok := ctx.Get(SpanKey).(*trace)
+	if !ok {
+		return
+	}
+
+	tr.mtx.Lock()
+	if tr.ended {
+		tr.mtx.Unlock()
+		return
+	}
+	tr.ended = true
+
+	span := tr.span
+	span.Name = "HTTP " + ctx.Request.Method
+	setAttributes(span, ctx.Request)
+	if err == nil && ctx.Response.StatusCode != 0 {
+		span.Attributes["http.response.status_code"] = ctx.Response.StatusCode
+	}
+	span.Err = err
+	span.End = time.Now()
+	tr.mtx.Unlock()
+
+	tr.export(span)
+}
+
+// child starts a new round trip span.
+func (tr *trace) child(req *http.Request) *Span {
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+
+	parent := tr.span.SpanContext
+	span := &Span{
+		Name:        "HTTP " + req.Method,
+		Kind:        SpanKindClient,
+		SpanContext: SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Flags: parent.Flags, TraceState: parent.TraceState},
+		Parent:      parent.SpanID,
+		Start:       time.Now(),
+		Attributes:  map[string]interface{}{},
+	}
+	setAttributes(span, req)
+	if tr.roundTrips > 0 {
+		span.Attributes["http.request.resend_count"] = tr.roundTrips
+	}
+	tr.roundTrips++
+	return span
+}
+
+// export exports the given span if sampled.
+func (tr *trace) export(span *Span) {
+	if tr.exporter != nil && span.SpanContext.IsSampled() {
+		tr.exporter.ExportSpan(span)
+	}
+}
+
+// setAttributes sets the request attributes of the given span.
+func setAttributes(span *Span, req *http.Request) {
+	span.Attributes["http.request.method"] = req.Method
+	span.Attributes["url.full"] = req.URL.Redacted()
+	span.Attributes["server.address"] = req.URL.Hostname()
+}
+
+// Transport is the http.RoundTripper installed before dialing for every traced request.
+// Each round trip, such as a redirect, retry or hedged attempt, gets its own client
+// span, child of the request span, whose context is propagated to the server.
+// Round trips are only traced while the transport stays in the client chain, so the
+// plugin must be registered before plugins restoring the transport, like retry.
+type Transport struct {
+	trace     *trace
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the required method by http.RoundTripper interface.
+// It is safe for concurrent use by hedged attempts of the same request.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	span := t.trace.child(req)
+
+	// Propagate the round trip span without altering the original request
+	traced := req.Clone(ContextWithSpanContext(req.Context(), span.SpanContext))
+	Inject(traced.Header, span.SpanContext, t.trace.baggage)
+
+	res, err := t.transport.RoundTrip(traced)
+	if err == nil {
+		span.Attributes["http.response.status_code"] = res.StatusCode
+	}
+	span.Err = err
+	span.End = time.Now()
+	t.trace.export(span)
+
+	return res, err
+}
@@ -0,0 +1,306 @@
+package tracing
+
+import (
+	"bytes"
+	gocontext "context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman"
+	c "github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/plugins/hedge"
+	"github.com/lytics/gentleman/plugins/retry"
+	"github.com/lytics/gentleman/plugins/retry/retrier"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	utils.Equal(t, err, nil)
+	utils.Equal(t, sc.TraceID.String(), "0af7651916cd43dd8448eb211c80319c")
+	utils.Equal(t, sc.SpanID.String(), "b7ad6b7169203331")
+	utils.Equal(t, sc.IsSampled(), true)
+	utils.Equal(t, sc.Traceparent(), "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+
+	sc, err = ParseTraceparent("01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00-future")
+	utils.Equal(t, err, nil)
+	utils.Equal(t, sc.IsSampled(), false)
+
+	invalid := []string{
+		"",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01",
+		"00-0AF7651916CD43DD8448EB211C80319C-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b716920333x-01",
+	}
+	for _, value := range invalid {
+		_, err := ParseTraceparent(value)
+		utils.Equal(t, err, ErrInvalidTraceparent)
+	}
+}
+
+func TestBaggage(t *testing.T) {
+	baggage := ParseBaggage("userId=alice, serverNode = DF%2028;prop=1,invalid, isProduction=false")
+	utils.Equal(t, baggage, Baggage{"userId": "alice", "serverNode": "DF 28", "isProduction": "false"})
+	utils.Equal(t, baggage.String(), "isProduction=false,serverNode=DF%2028,userId=alice")
+}
+
+func TestExtract(t *testing.T) {
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	header.Add(TracestateHeader, "congo=t61rcWkgMzE")
+	header.Add(TracestateHeader, "rojo=00f067aa0ba902b7")
+	header.Set(BaggageHeader, "k=v")
+
+	sc, baggage := Extract(header)
+	utils.Equal(t, sc.IsValid(), true)
+	utils.Equal(t, sc.TraceState, "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7")
+	utils.Equal(t, baggage, Baggage{"k": "v"})
+
+	sc, _ = Extract(http.Header{})
+	utils.Equal(t, sc.IsValid(), false)
+}
+
+func TestTracing(t *testing.T) {
+	var headers []http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header)
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	exporter := NewMemoryExporter()
+	cli := gentleman.New().URL(ts.URL).Use(New(Options{Exporter: exporter}))
+
+	var current *Span
+	cli.UseResponse(func(ctx *c.Context, h c.Handler) {
+		current = GetSpan(ctx)
+		h.Next(ctx)
+	})
+
+	res, err := cli.Request().Path("/users").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "hello")
+
+	spans := exporter.Spans()
+	utils.Equal(t, len(spans), 2)
+	attempt, span := spans[0], spans[1]
+	utils.Equal(t, span, current)
+
+	utils.Equal(t, span.Name, "HTTP GET")
+	utils.Equal(t, span.Kind, SpanKindInternal)
+	utils.Equal(t, span.Parent.IsValid(), false)
+	utils.Equal(t, span.SpanContext.IsSampled(), true)
+	utils.Equal(t, span.Attributes["url.full"], ts.URL+"/users")
+	utils.Equal(t, span.Attributes["http.response.status_code"], 200)
+	utils.Equal(t, span.End.Before(span.Start), false)
+
+	utils.Equal(t, attempt.Kind, SpanKindClient)
+	utils.Equal(t, attempt.Parent, span.SpanContext.SpanID)
+	utils.Equal(t, attempt.SpanContext.TraceID, span.SpanContext.TraceID)
+	utils.Equal(t, attempt.Attributes["http.response.status_code"], 200)
+	utils.Equal(t, attempt.Attributes["http.request.resend_count"], nil)
+
+	utils.Equal(t, len(headers), 1)
+	utils.Equal(t, headers[0].Get(TraceparentHeader), attempt.SpanContext.Traceparent())
+	utils.Equal(t, headers[0].Get(TracestateHeader), "")
+	utils.Equal(t, headers[0].Get(BaggageHeader), "")
+}
+
+func TestTracingParent(t *testing.T) {
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer ts.Close()
+
+	parent, _ := ParseTraceparent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	parent.TraceState = "congo=t61rcWkgMzE"
+	ctx := ContextWithSpanContext(gocontext.Background(), parent)
+	ctx = ContextWithBaggage(ctx, Baggage{"tenant": "acme"})
+
+	exporter := NewMemoryExporter()
+	cli := gentleman.New().URL(ts.URL).Use(New(Options{Exporter: exporter}))
+	_, err := cli.Request().DoContext(ctx)
+	utils.Equal(t, err, nil)
+
+	spans := exporter.Spans()
+	utils.Equal(t, len(spans), 2)
+	span := spans[1]
+	utils.Equal(t, span.Parent, parent.SpanID)
+	utils.Equal(t, span.SpanContext.TraceID, parent.TraceID)
+
+	sc, baggage := Extract(header)
+	utils.Equal(t, sc.TraceID, parent.TraceID)
+	utils.Equal(t, sc.SpanID, spans[0].SpanContext.SpanID)
+	utils.Equal(t, sc.TraceState, "congo=t61rcWkgMzE")
+	utils.Equal(t, baggage, Baggage{"tenant": "acme"})
+}
+
+func TestTracingNotSampled(t *testing.T) {
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer ts.Close()
+
+	exporter := NewMemoryExporter()
+	cli := gentleman.New().URL(ts.URL)
+	cli.Use(New(Options{Exporter: exporter, Sampler: func(*http.Request) bool { return false }}))
+	_, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, len(exporter.Spans()), 0)
+
+	sc, _ := Extract(header)
+	utils.Equal(t, sc.IsValid(), true)
+	utils.Equal(t, sc.IsSampled(), false)
+}
+
+func TestTracingRetryAndRedirect(t *testing.T) {
+	var calls int32
+	var parents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parents = append(parents, r.Header.Get(TraceparentHeader))
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/target", http.StatusFound)
+		case "/flaky":
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(503)
+				return
+			}
+			w.Write([]byte("ok"))
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer ts.Close()
+
+	exporter := NewMemoryExporter()
+	cli := gentleman.New().URL(ts.URL).Use(New(Options{Exporter: exporter}))
+	cli.Use(retry.New(retrier.New(retrier.ConstantBackoff(2, time.Millisecond), nil), nil))
+
+	res, err := cli.Request().Path("/flaky").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "ok")
+	res, err = cli.Request().Path("/redirect").Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "ok")
+
+	spans := exporter.Spans()
+	utils.Equal(t, len(spans), 6)
+	utils.Equal(t, len(parents), 4)
+
+	cases := []struct {
+		span   *Span
+		parent *Span
+		status int
+		url    string
+	}{
+		{spans[0], spans[2], 503, "/flaky"},
+		{spans[1], spans[2], 200, "/flaky"},
+		{spans[3], spans[5], 302, "/redirect"},
+		{spans[4], spans[5], 200, "/target"},
+	}
+	for i, test := range cases {
+		utils.Equal(t, test.span.Kind, SpanKindClient)
+		utils.Equal(t, test.span.Parent, test.parent.SpanContext.SpanID)
+		utils.Equal(t, test.span.Attributes["http.response.status_code"], test.status)
+		utils.Equal(t, test.span.Attributes["url.full"], ts.URL+test.url)
+		utils.Equal(t, parents[i], test.span.SpanContext.Traceparent())
+	}
+	utils.Equal(t, spans[1].Attributes["http.request.resend_count"], 1)
+	utils.Equal(t, spans[4].Attributes["http.request.resend_count"], 1)
+	utils.Equal(t, spans[5].Attributes["url.full"], ts.URL+"/redirect")
+}
+
+func TestTracingHedge(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	exporter := NewMemoryExporter()
+	cli := gentleman.New().URL(ts.URL).Use(New(Options{Exporter: exporter}))
+	cli.Use(hedge.New(hedge.Options{Delay: 10 * time.Millisecond}))
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.String(), "ok")
+
+	// The losing attempt span ends once canceled
+	for deadline := time.Now().Add(time.Second); len(exporter.Spans()) < 3 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	spans := exporter.Spans()
+	utils.Equal(t, len(spans), 3)
+	var parent SpanID
+	attempts := 0
+	for _, span := range spans {
+		if span.Kind == SpanKindInternal {
+			parent = span.SpanContext.SpanID
+		}
+	}
+	for _, span := range spans {
+		if span.Kind == SpanKindClient {
+			attempts++
+			utils.Equal(t, span.Parent, parent)
+		}
+	}
+	utils.Equal(t, attempts, 2)
+}
+
+func TestTracingError(t *testing.T) {
+	exporter := NewMemoryExporter()
+	cli := gentleman.New().URL("http://example.com").Use(New(Options{Exporter: exporter}))
+	cli.UseHandler("before dial", func(ctx *c.Context, h c.Handler) {
+		ctx.Client.Transport = failTransport{}
+		h.Next(ctx)
+	})
+
+	_, err := cli.Request().Send()
+	utils.NotEqual(t, err, nil)
+
+	spans := exporter.Spans()
+	utils.Equal(t, len(spans), 1)
+	utils.NotEqual(t, spans[0].Err, nil)
+	utils.Equal(t, spans[0].Attributes["http.response.status_code"], nil)
+}
+
+func TestLogExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewLogExporter(&buf)
+
+	sc, _ := ParseTraceparent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	exporter.ExportSpan(&Span{
+		Name:        "HTTP GET",
+		Kind:        SpanKindClient,
+		SpanContext: sc,
+		Start:       start,
+		End:         start.Add(15 * time.Millisecond),
+		Attributes:  map[string]interface{}{"url.full": "http://example.com", "http.response.status_code": 200},
+		Err:         errors.New("boom"),
+	})
+	utils.Equal(t, strings.TrimSpace(buf.String()), `span="HTTP GET" kind=client trace_id=0af7651916cd43dd8448eb211c80319c span_id=b7ad6b7169203331 duration=15ms http.response.status_code=200 url.full=http://example.com error=boom`)
+}
+
+type failTransport struct{}
+
+func (failTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
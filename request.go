@@ -1,6 +1,7 @@
 package gentleman
 
 import (
+	gocontext "context"
 	"errors"
 	"fmt"
 	"io"
@@ -21,8 +22,10 @@ import (
 	"github.com/lytics/gentleman/plugins/bodytype"
 	"github.com/lytics/gentleman/plugins/cookies"
 	"github.com/lytics/gentleman/plugins/headers"
+	"github.com/lytics/gentleman/plugins/limit"
 	"github.com/lytics/gentleman/plugins/multipart"
 	"github.com/lytics/gentleman/plugins/query"
+	"github.com/lytics/gentleman/plugins/status"
 	u "github.com/lytics/gentleman/plugins/url"
 )
 
@@ -246,6 +249,14 @@ func (r *Request) XML(data interface{}) *Request {
 	return r
 }
 
+// Encode serializes and defines the request body based on the given input,
+// using the codec registered for the given media type or type alias.
+// The proper Content-Type header will be transparently added for you.
+func (r *Request) Encode(data interface{}, mediaType string) *Request {
+	r.Use(body.Encode(data, mediaType))
+	return r
+}
+
 // Form serializes and defines the request body as multipart/form-data
 // based on the given form data.
 func (r *Request) Form(data multipart.FormData) *Request {
@@ -267,6 +278,29 @@ func (r *Request) Files(files []multipart.FormFile) *Request {
 	return r
 }
 
+// MaxResponseSize defines the maximum size in bytes of the response body.
+// Bigger responses fail with *limit.BodyTooLargeError.
+func (r *Request) MaxResponseSize(max int64) *Request {
+	r.Use(limit.Body(max))
+	return r
+}
+
+// SpillResponse reads the whole response body before the response phase,
+// keeping in memory up to the given amount of bytes and spilling bigger bodies
+// into a temporary file. Bodies bigger than max bytes fail with *limit.BodyTooLargeError.
+func (r *Request) SpillResponse(memory, max int64) *Request {
+	r.Use(limit.Spill(memory, max))
+	return r
+}
+
+// FailOnStatus reports responses with a status code within the given ranges
+// as *status.HTTPError via the error middleware phase.
+// By default, 4xx and 5xx status codes are considered failures.
+func (r *Request) FailOnStatus(ranges ...status.Range) *Request {
+	r.Use(status.Fail(ranges...))
+	return r
+}
+
 // Send is an alias to Do(), which executes the current request
 // and returns the response.
 func (r *Request) Send() (*Response, error) {
@@ -274,15 +308,60 @@ func (r *Request) Send() (*Response, error) {
 }
 
 // Do performs the HTTP request and returns the HTTP response.
+// If the parent Client defines a default context.Context, it will be used.
 func (r *Request) Do() (*Response, error) {
+	return r.DoContext(r.getContext())
+}
+
+// DoContext performs the HTTP request bound to the given context.Context
+// and returns the HTTP response.
+// Canceling the context aborts the in-flight request and stops the middleware
+// call chain, reporting a *CanceledError as response error.
+func (r *Request) DoContext(ctx gocontext.Context) (*Response, error) {
 	if r.dispatched {
 		return nil, errors.New("gentleman: Request was already dispatched")
 	}
 
 	r.dispatched = true
-	ctx := NewDispatcher(r).Dispatch()
+	if ctx != nil {
+		r.Context.SetContext(ctx)
+	}
+	dctx := NewDispatcher(r).Dispatch()
+
+	return buildResponse(dctx)
+}
 
-	return buildResponse(ctx)
+// DoAsync performs the HTTP request in a new goroutine
+// and returns a Future to consume the HTTP response.
+func (r *Request) DoAsync() *Future {
+	ctx := r.getContext()
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+	return r.DoAsyncContext(ctx)
+}
+
+// DoAsyncContext performs the HTTP request bound to the given context.Context
+// in a new goroutine and returns a Future to consume the HTTP response.
+// The request context is released once the response is closed, or when the
+// request fails, so either Future.Cancel or Response.Close must be called.
+func (r *Request) DoAsyncContext(ctx gocontext.Context) *Future {
+	ctx, cancel := gocontext.WithCancel(ctx)
+	f := newFuture(cancel)
+	go func() {
+		res, err := r.DoContext(ctx)
+		releaseOnClose(res, err, cancel)
+		f.resolve(res, err)
+	}()
+	return f
+}
+
+// getContext returns the default context.Context inherited from the Client, if any.
+func (r *Request) getContext() gocontext.Context {
+	if r.Client == nil {
+		return nil
+	}
+	return r.Client.getContext()
 }
 
 // Use uses a new plugin in the middleware stack.
@@ -359,7 +438,7 @@ func (r *Request) Dump() (string, error) {
 func NewDefaultTransport(dialer *net.Dialer) *http.Transport {
 	transport := &http.Transport{
 		Proxy:               http.ProxyFromEnvironment,
-		Dial:                dialer.Dial,
+		DialContext:         dialer.DialContext,
 		TLSHandshakeTimeout: TLSHandshakeTimeout,
 	}
 	return transport
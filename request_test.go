@@ -2,10 +2,12 @@ package gentleman
 
 import (
 	"bytes"
+	gocontext "context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -314,6 +316,134 @@ func TestRequestCancel(t *testing.T) {
 	utils.Equal(t, res.StatusCode, 0)
 }
 
+func TestRequestDoContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	res, err := NewRequest().URL(ts.URL).DoContext(gocontext.Background())
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 200)
+	utils.Equal(t, res.String(), "Hello, world\n")
+}
+
+func TestRequestDoContextCancelDial(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		fmt.Fprintln(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var phaseErr error
+	req := NewRequest().URL(ts.URL)
+	req.UseError(func(ctx *context.Context, h context.Handler) {
+		phaseErr = ctx.Error
+		h.Next(ctx)
+	})
+
+	res, err := req.DoContext(ctx)
+	utils.NotEqual(t, err, nil)
+	_, ok := err.(*CanceledError)
+	utils.Equal(t, ok, true)
+	utils.Equal(t, errors.Is(err, gocontext.DeadlineExceeded), true)
+	utils.Equal(t, phaseErr, err)
+	utils.Equal(t, res.Error, err)
+	utils.Equal(t, res.StatusCode, 0)
+}
+
+type dialKey struct{}
+
+func TestRequestDoContextCancelBlackholeDial(t *testing.T) {
+	// The blackhole resolver never answers, so the dial hangs
+	dialed := make(chan interface{}, 1)
+	dialer := &net.Dialer{Timeout: time.Minute, Resolver: &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx gocontext.Context, network, address string) (net.Conn, error) {
+			select {
+			case dialed <- ctx.Value(dialKey{}):
+			default:
+			}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}}
+
+	ctx, cancel := gocontext.WithCancel(gocontext.WithValue(gocontext.Background(), dialKey{}, "request"))
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	req := NewRequest().URL("http://blackhole.test")
+	req.Context.Client.Transport = NewDefaultTransport(dialer)
+
+	start := time.Now()
+	_, err := req.DoContext(ctx)
+	_, ok := err.(*CanceledError)
+	utils.Equal(t, ok, true)
+	utils.Equal(t, errors.Is(err, gocontext.Canceled), true)
+	utils.Equal(t, time.Since(start) < time.Second, true)
+
+	// The dial runs with the request context
+	utils.Equal(t, <-dialed, "request")
+}
+
+func TestRequestDoContextCancelPhases(t *testing.T) {
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+
+	var phases []string
+	req := NewRequest().URL("http://127.0.0.1:9123")
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		phases = append(phases, "request")
+		cancel()
+		h.Next(ctx)
+	})
+	req.UseHandler("before dial", func(ctx *context.Context, h context.Handler) {
+		phases = append(phases, "before dial")
+		h.Next(ctx)
+	})
+
+	res, err := req.DoContext(ctx)
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, errors.Is(err, gocontext.Canceled), true)
+	utils.Equal(t, phases, []string{"request"})
+	utils.Equal(t, res.StatusCode, 0)
+}
+
+func TestRequestMaxResponseSize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Transfer-Encoding", "chunked")
+		fmt.Fprint(w, strings.Repeat("x", 100))
+	}))
+	defer ts.Close()
+
+	res, err := NewRequest().URL(ts.URL).MaxResponseSize(50).Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.Bytes(), []byte(nil))
+	utils.Equal(t, res.Error.Error(), "limit: response body exceeds 50 bytes")
+
+	res, err = NewRequest().URL(ts.URL).MaxResponseSize(100).Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, len(res.Bytes()), 100)
+}
+
+func TestRequestSpillResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Transfer-Encoding", "chunked")
+		fmt.Fprint(w, strings.Repeat("x", 100))
+	}))
+	defer ts.Close()
+
+	res, err := NewRequest().URL(ts.URL).SpillResponse(10, 50).Send()
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, err.Error(), "limit: response body exceeds 50 bytes")
+
+	res, err = NewRequest().URL(ts.URL).SpillResponse(10, 100).Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, len(res.Bytes()), 100)
+}
+
 func TestRequestGoroutines(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(time.Duration(rand.Intn(50)) * time.Millisecond)
@@ -538,6 +668,16 @@ func TestRequestXML(t *testing.T) {
 	utils.Equal(t, string(body), `<xmlTest><name><first>foo</first></name></xmlTest>`)
 }
 
+func TestRequestEncode(t *testing.T) {
+	req := NewRequest()
+	req.Encode(url.Values{"foo": []string{"bar"}}, "urlencoded")
+	req.Middleware.Run("request", req.Context)
+	utils.Equal(t, int(req.Context.Request.ContentLength), 7)
+	utils.Equal(t, req.Context.Request.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+	body, _ := ioutil.ReadAll(req.Context.Request.Body)
+	utils.Equal(t, string(body), "foo=bar")
+}
+
 func TestRequestForm(t *testing.T) {
 	reader := bytes.NewReader([]byte("hello world"))
 	fields := map[string]multipart.Values{
@@ -594,6 +734,19 @@ func TestRequestClone(t *testing.T) {
 	utils.Equal(t, len(req2.Middleware.GetStack()), 1)
 }
 
+func TestRequestCloneCanceled(t *testing.T) {
+	parent, cancel := gocontext.WithCancel(gocontext.Background())
+	req := NewRequest().URL("http://example.com")
+	req.Context.SetContext(parent)
+	clone := req.Clone()
+
+	cancel()
+	_, err := clone.Send()
+	var canceledErr *CanceledError
+	utils.Equal(t, errors.As(err, &canceledErr), true)
+	utils.Equal(t, errors.Is(err, gocontext.Canceled), true)
+}
+
 func BenchmarkSimpleRequestGet(b *testing.B) {
 	ts := createEchoServer()
 	defer ts.Close()
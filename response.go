@@ -5,7 +5,6 @@ package gentleman
 
 import (
 	"bytes"
-	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -13,7 +12,9 @@ import (
 	"net/http/httputil"
 	"os"
 
+	"github.com/lytics/gentleman/codec"
 	"github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/plugins/status"
 	"github.com/lytics/gentleman/utils"
 )
 
@@ -50,6 +51,10 @@ type Response struct {
 	// Expose original request Context for convenience.
 	Context *context.Context
 
+	// Timings stores the timing breakdown of the HTTP transaction.
+	// It is nil if the request was not dialed, such as intercepted requests.
+	Timings *Timings
+
 	// Internal buffer store
 	buffer *bytes.Buffer
 }
@@ -70,6 +75,7 @@ func buildResponse(ctx *context.Context) (*Response, error) {
 		StatusCode:  resp.StatusCode,
 		Header:      resp.Header,
 		Cookies:     resp.Cookies(),
+		Timings:     GetTimings(ctx),
 		buffer:      bytes.NewBuffer([]byte{}),
 	}
 
@@ -87,11 +93,15 @@ func (r *Response) Read(p []byte) (n int, err error) {
 
 // Close is part of our ability to support io.ReadCloser if
 // someone wants to make use of the raw body.
+// The raw body is closed even if the response failed.
 func (r *Response) Close() error {
-	if r.Error != nil {
-		return r.Error
+	if r.RawResponse != nil && r.RawResponse.Body != nil {
+		err := r.RawResponse.Body.Close()
+		if r.Error == nil {
+			return err
+		}
 	}
-	return r.RawResponse.Body.Close()
+	return r.Error
 }
 
 // SaveToFile allows you to download the contents
@@ -124,10 +134,24 @@ func (r *Response) JSON(userStruct interface{}) error {
 		return r.Error
 	}
 
-	jsonDecoder := json.NewDecoder(r.getInternalReader())
+	return r.decode(&userStruct, "application/json")
+}
+
+// Decode is a method that will populate the given value with the response body
+// decoded by the codec registered for the response Content-Type.
+func (r *Response) Decode(v interface{}) error {
+	if r.Error != nil {
+		return r.Error
+	}
+
+	return r.decode(v, r.Header.Get("Content-Type"))
+}
+
+// decode decodes the response body based on the given media type codec.
+func (r *Response) decode(v interface{}, mediaType string) error {
 	defer r.Close()
 
-	err := jsonDecoder.Decode(&userStruct)
+	err := codec.Decode(r.getInternalReader(), v, mediaType)
 	if err != nil && err != io.EOF {
 		return err
 	}
@@ -155,6 +179,32 @@ func (r *Response) XML(userStruct interface{}, charsetReader utils.XMLCharDecode
 	return nil
 }
 
+// Problem decodes the response body as a problem details document (RFC 9457).
+// Returns false if the response Content-Type is not application/problem+json,
+// the document exceeds status.ProblemLimit or it cannot be decoded.
+// The body can still be consumed afterwards.
+// Use Problem.Err() to obtain the registered custom error for the problem.
+func (r *Response) Problem() (*status.Problem, bool) {
+	if r.Error != nil || !status.IsProblem(r.Header) {
+		return nil, false
+	}
+
+	var problem *status.Problem
+	var err error
+	if r.buffer.Len() != 0 {
+		if int64(r.buffer.Len()) > status.ProblemLimit {
+			return nil, false
+		}
+		problem, err = status.ParseProblem(r.buffer.Bytes())
+	} else {
+		problem, err = status.ReadProblem(r.RawResponse)
+	}
+	if err != nil {
+		return nil, false
+	}
+	return problem, true
+}
+
 // Bytes returns the response as a byte array.
 func (r *Response) Bytes() []byte {
 	if r.Error != nil {
@@ -228,6 +278,7 @@ func (r *Response) populateResponseByteBuffer() {
 	_, err := io.Copy(r.buffer, r)
 	if err != nil && err != io.EOF {
 		r.Error = err
+		r.buffer.Reset()
 		r.RawResponse.Body.Close()
 	}
 }
@@ -1,13 +1,20 @@
 package gentleman
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/lytics/gentleman/plugins/status"
 	"github.com/lytics/gentleman/utils"
 )
 
@@ -104,6 +111,69 @@ func TestResponseCloseError(t *testing.T) {
 	utils.Equal(t, err.Error(), "foo error")
 }
 
+func TestResponseCloseFailedBody(t *testing.T) {
+	body := &closeRecorder{}
+	ctx := NewContext()
+	ctx.Error = errors.New("foo error")
+	ctx.Response.Body = body
+	res, _ := buildResponse(ctx)
+	utils.Equal(t, res.Close(), ctx.Error)
+	utils.Equal(t, body.closed, true)
+}
+
+func TestResponseFailStatusReleasesConnection(t *testing.T) {
+	var mtx sync.Mutex
+	active := map[net.Conn]bool{}
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Large enough to block the server until the client reads or closes the body
+		w.WriteHeader(404)
+		w.Write(bytes.Repeat([]byte("x"), 8<<20))
+	}))
+	ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		active[conn] = state == http.StateActive
+	}
+	ts.Start()
+	defer ts.Close()
+	// Leaked connections would otherwise block the server close
+	defer ts.CloseClientConnections()
+
+	cli := New().URL(ts.URL).Use(status.Fail())
+	for i := 0; i < 3; i++ {
+		_, err := cli.Request().Send()
+		utils.NotEqual(t, err, nil)
+	}
+
+	open := func() (n int) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		for _, ok := range active {
+			if ok {
+				n++
+			}
+		}
+		return n
+	}
+	for deadline := time.Now().Add(2 * time.Second); open() > 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	utils.Equal(t, open(), 0)
+}
+
+type closeRecorder struct {
+	closed bool
+}
+
+func (c *closeRecorder) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
 func TestResponseSaveToFile(t *testing.T) {
 	ctx := NewContext()
 	utils.WriteBodyString(ctx.Response, "hello world")
@@ -178,6 +248,83 @@ func TestResponseXMLError(t *testing.T) {
 	utils.Equal(t, xmlData.Foo, "")
 }
 
+func TestResponseDecode(t *testing.T) {
+	type data struct {
+		Foo string `json:"foo" xml:"foo"`
+	}
+
+	cases := []struct {
+		kind string
+		body string
+	}{
+		{"application/json; charset=utf-8", `{"foo":"bar"}`},
+		{"application/vnd.api+json", `{"foo":"bar"}`},
+		{"text/xml", `<data><foo>bar</foo></data>`},
+	}
+
+	for _, test := range cases {
+		ctx := NewContext()
+		ctx.Response.Header.Set("Content-Type", test.kind)
+		utils.WriteBodyString(ctx.Response, test.body)
+		res, _ := buildResponse(ctx)
+
+		value := &data{}
+		utils.Equal(t, res.Decode(value), nil)
+		utils.Equal(t, value.Foo, "bar")
+	}
+}
+
+func TestResponseDecodeUnsupported(t *testing.T) {
+	ctx := NewContext()
+	ctx.Response.Header.Set("Content-Type", "application/x-foo")
+	utils.WriteBodyString(ctx.Response, "foo")
+	res, _ := buildResponse(ctx)
+
+	var value string
+	err := res.Decode(&value)
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, err.Error(), "codec: unsupported media type: application/x-foo")
+}
+
+func TestResponseProblem(t *testing.T) {
+	ctx := NewContext()
+	ctx.Response.StatusCode = 404
+	ctx.Response.Header.Set("Content-Type", "application/problem+json")
+	utils.WriteBodyString(ctx.Response, `{"title":"Not found","status":404,"resource":"foo"}`)
+	res, _ := buildResponse(ctx)
+
+	problem, ok := res.Problem()
+	utils.Equal(t, ok, true)
+	utils.Equal(t, problem.Title, "Not found")
+	utils.Equal(t, problem.Status, 404)
+	utils.Equal(t, problem.Extensions["resource"], "foo")
+	utils.Equal(t, res.String(), `{"title":"Not found","status":404,"resource":"foo"}`)
+
+	problem, ok = res.Problem()
+	utils.Equal(t, ok, true)
+	utils.Equal(t, problem.Title, "Not found")
+
+	ctx = NewContext()
+	utils.WriteBodyString(ctx.Response, `{"title":"Not found"}`)
+	res, _ = buildResponse(ctx)
+	_, ok = res.Problem()
+	utils.Equal(t, ok, false)
+}
+
+func TestResponseProblemLimit(t *testing.T) {
+	defer func(limit int64) { status.ProblemLimit = limit }(status.ProblemLimit)
+	status.ProblemLimit = 10
+
+	ctx := NewContext()
+	ctx.Response.Header.Set("Content-Type", "application/problem+json")
+	utils.WriteBodyString(ctx.Response, `{"title":"Not found"}`)
+	res, _ := buildResponse(ctx)
+
+	_, ok := res.Problem()
+	utils.Equal(t, ok, false)
+	utils.Equal(t, res.String(), `{"title":"Not found"}`)
+}
+
 func TestResponseString(t *testing.T) {
 	ctx := NewContext()
 	utils.WriteBodyString(ctx.Response, "foo bar")
@@ -0,0 +1,189 @@
+package gentleman
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// MaxRecordSize defines the default maximum size in bytes
+// of a single record read via the response streaming API.
+var MaxRecordSize = 1024 * 1024
+
+// Scanner provides incremental record iteration over a response body
+// without buffering it entirely in memory.
+// Records bigger than the max record size fail with bufio.ErrTooLong.
+type Scanner struct {
+	// res stores the response being scanned
+	res *Response
+
+	// split stores the record split function
+	split bufio.SplitFunc
+
+	// max stores the maximum record size
+	max int
+
+	// scanner is lazily created on the first iteration
+	scanner *bufio.Scanner
+
+	// err stores the response or read error, if any
+	err error
+}
+
+// newScanner creates a new Scanner based on the given split function.
+func newScanner(res *Response, split bufio.SplitFunc) *Scanner {
+	return &Scanner{res: res, split: split, max: MaxRecordSize, err: res.Error}
+}
+
+// Lines returns a Scanner to iterate over the response body lines.
+// Line terminators, including optional carriage returns, are stripped.
+func (r *Response) Lines() *Scanner {
+	return newScanner(r, bufio.ScanLines)
+}
+
+// Split returns a Scanner to iterate over the response body records
+// separated by the given delimiter. The delimiter is stripped from records.
+// An empty delimiter behaves like Lines.
+func (r *Response) Split(delim []byte) *Scanner {
+	if len(delim) == 0 {
+		return r.Lines()
+	}
+	return newScanner(r, splitDelimiter(delim))
+}
+
+// NDJSON returns a decoder to iterate over the newline delimited JSON
+// records of the response body.
+func (r *Response) NDJSON() *NDJSONDecoder {
+	return &NDJSONDecoder{Scanner: r.Lines()}
+}
+
+// MaxSize defines the maximum size in bytes of a single record.
+// Non-positive sizes restore the default MaxRecordSize.
+// Must be called before the first iteration.
+func (s *Scanner) MaxSize(size int) *Scanner {
+	if size <= 0 {
+		size = MaxRecordSize
+	}
+	s.max = size
+	return s
+}
+
+// Next advances the scanner to the next record, which will then be available
+// through the Bytes or Text methods. It returns false when the scan stops,
+// either by reaching the end of the body or an error.
+// The response body is closed once the scan stops.
+func (s *Scanner) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	if s.scanner == nil {
+		s.scanner = bufio.NewScanner(s.res.getInternalReader())
+		s.scanner.Buffer(make([]byte, 0, minInt(bufio.MaxScanTokenSize, s.max)), s.max)
+		s.scanner.Split(s.split)
+	}
+
+	if s.scanner.Scan() {
+		return true
+	}
+
+	s.err = s.scanner.Err()
+	if s.err == nil {
+		s.err = io.EOF
+	}
+	s.res.Close()
+	return false
+}
+
+// Bytes returns the most recent record read by Next.
+// The underlying array may be overwritten by a subsequent call to Next.
+func (s *Scanner) Bytes() []byte {
+	if s.scanner == nil {
+		return nil
+	}
+	return s.scanner.Bytes()
+}
+
+// Text returns the most recent record read by Next as string.
+func (s *Scanner) Text() string {
+	return string(s.Bytes())
+}
+
+// Err returns the first non-EOF error that was encountered while scanning.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// Close stops the scan, closing the response body.
+func (s *Scanner) Close() error {
+	if s.err == nil {
+		s.err = io.EOF
+	}
+	return s.res.Close()
+}
+
+// NDJSONDecoder decodes newline delimited JSON records
+// from a response body incrementally. Blank lines are ignored.
+type NDJSONDecoder struct {
+	*Scanner
+
+	// line stores the current line number
+	line int
+}
+
+// MaxSize defines the maximum size in bytes of a single record.
+// Non-positive sizes restore the default MaxRecordSize.
+// Must be called before the first decoding.
+func (d *NDJSONDecoder) MaxSize(size int) *NDJSONDecoder {
+	d.Scanner.MaxSize(size)
+	return d
+}
+
+// Decode reads the next JSON record and stores it in the value pointed to by v.
+// Returns io.EOF when there are no more records.
+func (d *NDJSONDecoder) Decode(v interface{}) error {
+	for d.Next() {
+		d.line++
+		record := bytes.TrimSpace(d.Bytes())
+		if len(record) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(record, v); err != nil {
+			return fmt.Errorf("gentleman: invalid JSON record at line %d: %w", d.line, err)
+		}
+		return nil
+	}
+
+	if err := d.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// splitDelimiter returns a bufio.SplitFunc splitting records by the given delimiter.
+func splitDelimiter(delim []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,137 @@
+package gentleman
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lytics/gentleman/utils"
+)
+
+type errorReader struct {
+	data []byte
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("read error")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func (r *errorReader) Close() error {
+	return nil
+}
+
+func TestResponseLines(t *testing.T) {
+	ctx := NewContext()
+	utils.WriteBodyString(ctx.Response, "foo\r\nbar\n\nbaz")
+	res, _ := buildResponse(ctx)
+
+	lines := []string{}
+	scanner := res.Lines()
+	for scanner.Next() {
+		lines = append(lines, scanner.Text())
+	}
+	utils.Equal(t, scanner.Err(), nil)
+	utils.Equal(t, lines, []string{"foo", "bar", "", "baz"})
+	utils.Equal(t, scanner.Next(), false)
+}
+
+func TestResponseSplit(t *testing.T) {
+	ctx := NewContext()
+	utils.WriteBodyString(ctx.Response, "foo||bar||baz||")
+	res, _ := buildResponse(ctx)
+
+	records := []string{}
+	scanner := res.Split([]byte("||"))
+	for scanner.Next() {
+		records = append(records, scanner.Text())
+	}
+	utils.Equal(t, scanner.Err(), nil)
+	utils.Equal(t, records, []string{"foo", "bar", "baz"})
+}
+
+func TestResponseScannerMaxSize(t *testing.T) {
+	ctx := NewContext()
+	utils.WriteBodyString(ctx.Response, "foo\nfoobarbaz\nbar")
+	res, _ := buildResponse(ctx)
+
+	scanner := res.Lines().MaxSize(5)
+	utils.Equal(t, scanner.Next(), true)
+	utils.Equal(t, scanner.Text(), "foo")
+	utils.Equal(t, scanner.Next(), false)
+	utils.Equal(t, scanner.Err(), bufio.ErrTooLong)
+}
+
+func TestResponseScannerInvalidMaxSize(t *testing.T) {
+	ctx := NewContext()
+	utils.WriteBodyString(ctx.Response, "foo\nbar")
+	res, _ := buildResponse(ctx)
+
+	scanner := res.Lines().MaxSize(-1)
+	utils.Equal(t, scanner.max, MaxRecordSize)
+	utils.Equal(t, scanner.Next(), true)
+	utils.Equal(t, scanner.Text(), "foo")
+
+	ctx = NewContext()
+	utils.WriteBodyString(ctx.Response, `{"id":1}`)
+	res, _ = buildResponse(ctx)
+
+	decoder := res.NDJSON().MaxSize(0)
+	var record struct{ ID int }
+	utils.Equal(t, decoder.Decode(&record), nil)
+	utils.Equal(t, record.ID, 1)
+}
+
+func TestResponseScannerReadError(t *testing.T) {
+	ctx := NewContext()
+	ctx.Response.Body = &errorReader{[]byte("foo\nbar")}
+	res, _ := buildResponse(ctx)
+
+	scanner := res.Lines()
+	utils.Equal(t, scanner.Next(), true)
+	utils.Equal(t, scanner.Text(), "foo")
+	utils.Equal(t, scanner.Next(), true)
+	utils.Equal(t, scanner.Text(), "bar")
+	utils.Equal(t, scanner.Next(), false)
+	utils.Equal(t, scanner.Err().Error(), "read error")
+}
+
+func TestResponseScannerError(t *testing.T) {
+	ctx := NewContext()
+	ctx.Error = errors.New("foo error")
+	res, _ := buildResponse(ctx)
+
+	scanner := res.Lines()
+	utils.Equal(t, scanner.Next(), false)
+	utils.Equal(t, scanner.Err().Error(), "foo error")
+}
+
+func TestResponseNDJSON(t *testing.T) {
+	type record struct {
+		ID int `json:"id"`
+	}
+
+	ctx := NewContext()
+	utils.WriteBodyString(ctx.Response, "{\"id\":1}\n\n{\"id\":2}\n{\"id\":")
+	res, _ := buildResponse(ctx)
+
+	decoder := res.NDJSON()
+	rec := record{}
+	utils.Equal(t, decoder.Decode(&rec), nil)
+	utils.Equal(t, rec.ID, 1)
+	utils.Equal(t, decoder.Decode(&rec), nil)
+	utils.Equal(t, rec.ID, 2)
+
+	err := decoder.Decode(&rec)
+	utils.NotEqual(t, err, nil)
+	utils.Equal(t, err.Error(), "gentleman: invalid JSON record at line 4: unexpected end of JSON input")
+	var syntaxErr *json.SyntaxError
+	utils.Equal(t, errors.As(err, &syntaxErr), true)
+	utils.Equal(t, decoder.Decode(&rec), io.EOF)
+}
@@ -0,0 +1,198 @@
+package gentleman
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/lytics/gentleman/context"
+)
+
+// TimingsKey stores the context store key of the request Timings.
+const TimingsKey = "$timings"
+
+// Timings stores the timing breakdown of a dialed HTTP transaction,
+// collected via net/http/httptrace.
+// If the request is redirected or retried, the connection timings
+// refer to the last connection used, while the time to first byte
+// and total time cover the whole transaction.
+//
+// ContentTransfer and Total are updated by the goroutine consuming the response
+// body, once it is fully read or closed. Read the fields from that goroutine
+// afterwards, or use Snapshot from other goroutines.
+type Timings struct {
+	// DNSLookup stores the time spent resolving the host name.
+	DNSLookup time.Duration
+
+	// TCPConnection stores the time spent establishing the TCP connection.
+	TCPConnection time.Duration
+
+	// TLSHandshake stores the time spent in the TLS handshake.
+	TLSHandshake time.Duration
+
+	// TimeToFirstByte stores the time since the request was dialed
+	// until the first response byte was received.
+	TimeToFirstByte time.Duration
+
+	// ContentTransfer stores the time spent reading the response body.
+	// It is set once the body has been fully read or closed.
+	ContentTransfer time.Duration
+
+	// Total stores the total time of the transaction. Until the response body
+	// has been fully read or closed, it stores the time until the response
+	// headers were received.
+	Total time.Duration
+
+	// Reused reports whether the connection was reused from the pool.
+	Reused bool
+
+	// RemoteAddr stores the server network address.
+	RemoteAddr string
+
+	// body stores the timed response body updating the timings, if any
+	body *timedBody
+}
+
+// Snapshot returns a copy of the timings, safe to use concurrently
+// with the response body consumption.
+func (t *Timings) Snapshot() Timings {
+	if t.body == nil {
+		return *t
+	}
+	t.body.mtx.Lock()
+	defer t.body.mtx.Unlock()
+	return *t
+}
+
+// GetTimings returns the Timings stored in the given Context, if present.
+// It is available from the "after dial" phase of dialed requests.
+func GetTimings(ctx *context.Context) *Timings {
+	timings, _ := ctx.Get(TimingsKey).(*Timings)
+	return timings
+}
+
+// tracer collects the timings of a HTTP transaction.
+type tracer struct {
+	mtx       sync.Mutex
+	start     time.Time
+	dnsStart  time.Time
+	connStart time.Time
+	tlsStart  time.Time
+	timings   Timings
+}
+
+// newTracer creates a new tracer starting at the current time.
+func newTracer() *tracer {
+	return &tracer{start: time.Now()}
+}
+
+// clientTrace returns the httptrace hooks collecting the timings.
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mtx.Lock()
+			defer t.mtx.Unlock()
+			t.timings.DNSLookup, t.timings.TCPConnection, t.timings.TLSHandshake = 0, 0, 0
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mark(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.since(t.dnsStart, &t.timings.DNSLookup)
+		},
+		ConnectStart: func(string, string) {
+			t.mark(&t.connStart)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.since(t.connStart, &t.timings.TCPConnection)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mark(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.since(t.tlsStart, &t.timings.TLSHandshake)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mtx.Lock()
+			defer t.mtx.Unlock()
+			t.timings.Reused = info.Reused
+			t.timings.RemoteAddr = info.Conn.RemoteAddr().String()
+		},
+		GotFirstResponseByte: func() {
+			t.since(t.start, &t.timings.TimeToFirstByte)
+		},
+	}
+}
+
+// mark stores the current time in the given field.
+func (t *tracer) mark(field *time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	*field = time.Now()
+}
+
+// since stores the time elapsed since the given start in the given field.
+func (t *tracer) since(start time.Time, field *time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	*field = time.Since(start)
+}
+
+// done returns the collected timings once the response headers were received,
+// wrapping the response body to measure the content transfer.
+func (t *tracer) done(res *http.Response) *Timings {
+	t.mtx.Lock()
+	timings := t.timings
+	t.mtx.Unlock()
+
+	timings.Total = time.Since(t.start)
+	if res != nil && res.Body != nil {
+		// Upgraded connections bodies are writable and must not be wrapped
+		if _, ok := res.Body.(io.Writer); !ok {
+			timings.body = &timedBody{ReadCloser: res.Body, timings: &timings, start: t.start}
+			res.Body = timings.body
+		}
+	}
+	return &timings
+}
+
+// timedBody measures the response body transfer time.
+type timedBody struct {
+	io.ReadCloser
+	timings *Timings
+	start   time.Time
+	once    sync.Once
+
+	// mtx protects the timings updated once the body is consumed
+	mtx sync.Mutex
+}
+
+// Read implements the io.Reader interface.
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (b *timedBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+// finish sets the content transfer and total times.
+func (b *timedBody) finish() {
+	b.once.Do(func() {
+		b.mtx.Lock()
+		defer b.mtx.Unlock()
+		b.timings.Total = time.Since(b.start)
+		b.timings.ContentTransfer = b.timings.Total - b.timings.TimeToFirstByte
+	})
+}
@@ -0,0 +1,88 @@
+package gentleman
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lytics/gentleman/context"
+	"github.com/lytics/gentleman/utils"
+)
+
+func TestTimings(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	var afterDial *Timings
+	cli := New().URL(ts.URL)
+	cli.UseHandler("after dial", func(ctx *context.Context, h context.Handler) {
+		afterDial = GetTimings(ctx)
+		h.Next(ctx)
+	})
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.NotEqual(t, res.Timings, (*Timings)(nil))
+	utils.Equal(t, afterDial, res.Timings)
+
+	timings := res.Timings
+	utils.Equal(t, timings.Reused, false)
+	utils.Equal(t, timings.RemoteAddr, ts.Listener.Addr().String())
+	utils.Equal(t, timings.TCPConnection > 0, true)
+	utils.Equal(t, timings.TimeToFirstByte > 0, true)
+	utils.Equal(t, timings.ContentTransfer, time.Duration(0))
+
+	utils.Equal(t, res.String(), "hello")
+	utils.Equal(t, timings.ContentTransfer >= 20*time.Millisecond, true)
+	utils.Equal(t, timings.Total, timings.TimeToFirstByte+timings.ContentTransfer)
+
+	res, err = cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.Timings.Reused, true)
+	utils.Equal(t, res.Timings.TCPConnection, time.Duration(0))
+}
+
+func TestTimingsSnapshot(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	res, err := New().URL(ts.URL).Request().Send()
+	utils.Equal(t, err, nil)
+
+	done := make(chan Timings)
+	go func() {
+		// Read concurrently with the body consumption
+		snapshot := res.Timings.Snapshot()
+		for snapshot.ContentTransfer == 0 {
+			time.Sleep(time.Millisecond)
+			snapshot = res.Timings.Snapshot()
+		}
+		done <- snapshot
+	}()
+
+	utils.Equal(t, res.String(), "hello")
+	snapshot := <-done
+	utils.Equal(t, snapshot.Total, snapshot.TimeToFirstByte+snapshot.ContentTransfer)
+	utils.Equal(t, (&Timings{Reused: true}).Snapshot().Reused, true)
+}
+
+func TestTimingsIntercepted(t *testing.T) {
+	cli := New().URL("http://example.com")
+	cli.UseRequest(func(ctx *context.Context, h context.Handler) {
+		ctx.Response.StatusCode = 201
+		h.Next(ctx)
+	})
+
+	res, err := cli.Request().Send()
+	utils.Equal(t, err, nil)
+	utils.Equal(t, res.StatusCode, 201)
+	utils.Equal(t, res.Timings, (*Timings)(nil))
+}